@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"pomodoro/runner"
+	"text/tabwriter"
+)
+
+// Print every problem with the config and the flags. Returns the exit code
+func checkConfig(path, profile string, flags timerFlags) int {
+	errs := runner.CheckConfig(path, profile)
+	var cfg runner.Config
+	if err := flags.apply(&cfg); err != nil {
+		errs = append(errs, err)
+	}
+	printErrors(errs)
+	if len(errs) > 0 {
+		return 1
+	}
+	fmt.Println(path + ": OK")
+	return 0
+}
+
+func showConfig(path string) int {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "Config file "+path+" not found. Create it with pomodoro config init")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Print(string(data))
+	return 0
+}
+
+// Print every value in use with where it came from: the defaults, the file,
+// the profile, the environment or the flags
+func showEffective(path, profile string, flags timerFlags) int {
+	cfg, errs := runner.ReadConfig(path, profile)
+	printErrors(errs)
+	err := flags.apply(&cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	header := "# " + path
+	if cfg.Profile() != "" {
+		header += ", profile \"" + cfg.Profile() + "\""
+	}
+	fmt.Println(header)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, value := range cfg.Values() {
+		data, _ := json.Marshal(value.Value)
+		fmt.Fprintf(writer, "%s = %s\t# %s\n", value.Key, data, value.Source)
+	}
+	writer.Flush()
+	return 0
+}
+
+func getValue(path, profile, key string) int {
+	if key == "" {
+		fmt.Fprintln(os.Stderr, "Expected a key, e.g. work_time")
+		return 1
+	}
+	cfg, errs := runner.ReadConfig(path, profile)
+	printErrors(errs)
+	value, err := cfg.Get(key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(value.Text())
+	return 0
+}
@@ -3,9 +3,12 @@ module pomodoro
 go 1.19
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/akamensky/argparse v1.4.0
 	github.com/faiface/beep v1.1.0
 	github.com/gdamore/tcell/v2 v2.6.0
+	golang.org/x/term v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -20,6 +23,5 @@ require (
 	golang.org/x/image v0.0.0-20190227222117-0694c2d4d067 // indirect
 	golang.org/x/mobile v0.0.0-20190415191353-3e0bab5405d6 // indirect
 	golang.org/x/sys v0.5.0 // indirect
-	golang.org/x/term v0.5.0 // indirect
 	golang.org/x/text v0.7.0 // indirect
 )
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"pomodoro/history"
+	"pomodoro/runner"
+	"pomodoro/view"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const DATE_FORMAT = "Mon 2006-01-02"
+
+// The history store of the config at path
+func openHistory(path, profile string) *history.Store {
+	cfg, errs := runner.ReadConfig(path, profile)
+	printErrors(errs)
+	return history.NewStore(cfg.HistoryPath())
+}
+
+// Print the totals for today and this week, or for each of the last days,
+// followed by the progress towards the goals in the config
+func printStats(path, profile string, days int) int {
+	cfg, errs := runner.ReadConfig(path, profile)
+	printErrors(errs)
+	now := time.Now()
+	week := history.StartOfWeek(now)
+	from := week
+	if days > 0 {
+		from = history.StartOfDay(now).AddDate(0, 0, 1-days)
+	}
+	read := from
+	if week.Before(read) {
+		read = week
+	}
+	all, err := history.NewStore(cfg.HistoryPath()).Read(read, time.Time{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	entries := history.Between(all, from, time.Time{})
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	total := "This week"
+	if days > 0 {
+		total = "Total"
+		for i := 0; i < days; i++ {
+			start := from.AddDate(0, 0, i)
+			day := history.Between(entries, start, start.AddDate(0, 0, 1))
+			printSummary(writer, start.Format(DATE_FORMAT), history.Summarize(day))
+		}
+	} else {
+		today := history.Between(entries, history.StartOfDay(now), time.Time{})
+		printSummary(writer, "Today", history.Summarize(today))
+	}
+	printSummary(writer, total, history.Summarize(entries))
+	writer.Flush()
+	goals := cfg.Goals(
+		history.Summarize(history.Between(all, history.StartOfDay(now), time.Time{})),
+		history.Summarize(history.Between(all, week, time.Time{})),
+	)
+	if len(goals) > 0 {
+		fmt.Println("\nGoals: " + view.GoalText(goals, cfg.WorkChar))
+	}
+	return 0
+}
+
+func printSummary(writer *tabwriter.Writer, label string, summary history.Summary) {
+	fmt.Fprintf(
+		writer, "%s\t%s pomodoros\t%s unfinished\t%s abandoned\t%s work\t%s breaks\n",
+		label,
+		strconv.Itoa(summary.Pomodoros),
+		strconv.Itoa(summary.Unfinished),
+		strconv.Itoa(summary.Abandoned),
+		runner.Duration(summary.WorkTime),
+		runner.Duration(summary.BreakTime),
+	)
+}
+
+// Print the last limit phases, oldest first. 0 prints all of them
+func printHistory(path, profile string, limit int) int {
+	entries, err := openHistory(path, profile).Read(time.Time{}, time.Time{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Println("No history yet")
+		return 0
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, entry := range entries {
+		result := "done"
+		if entry.Abandoned {
+			result = "abandoned"
+		} else if !entry.Completed {
+			result = "unfinished"
+		}
+		fmt.Fprintf(
+			writer, "%s %s-%s\t%s\t%s/%s\t%s\t%s\n",
+			entry.Start.Format(DATE_FORMAT),
+			entry.Start.Format("15:04"),
+			entry.End.Format("15:04"),
+			entry.Phase,
+			runner.Duration(entry.Actual),
+			runner.Duration(entry.Planned),
+			result,
+			entry.Task,
+		)
+	}
+	writer.Flush()
+	return 0
+}
+
+type exportOptions struct {
+	// One of history.EXPORT_FORMATS, or "" to pick it from the output
+	format string
+	// File to write to, or "" for stdout
+	output string
+	// Dates like 2024-03-01. Both days are included and "" leaves that end open
+	from, to string
+}
+
+// Write the recorded phases between two days to a file or stdout
+func exportHistory(path, profile string, options exportOptions) int {
+	var from, to time.Time
+	var err error
+	if options.from != "" {
+		from, err = history.ParseDate(options.from)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if options.to != "" {
+		to, err = history.ParseDate(options.to)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	format := options.format
+	if format == "" {
+		format = history.FormatOf(options.output)
+	}
+	if format == "" {
+		format = history.FORMAT_JSON
+	}
+	entries, err := openHistory(path, profile).Read(from, to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if options.output == "" {
+		err = history.Export(os.Stdout, format, entries)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	file, err := os.Create(options.output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	err = history.Export(file, format, entries)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return report(err, "Wrote "+options.output)
+}
@@ -0,0 +1,214 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"pomodoro/timer"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FORMAT_CSV  = "csv"
+	FORMAT_JSON = "json"
+	FORMAT_ICS  = "ics"
+)
+
+var EXPORT_FORMATS = []string{FORMAT_CSV, FORMAT_JSON, FORMAT_ICS}
+
+// Dates for --from and --to, e.g. 2024-03-01
+const DATE_LAYOUT = "2006-01-02"
+
+const (
+	ICS_PRODID = "-//pomodoro-cli//history//EN"
+	ICS_TIME   = "20060102T150405Z"
+	// Longest line allowed in an iCalendar file, in bytes
+	ICS_LINE_LENGTH = 75
+)
+
+// Midnight at the start of a date like 2024-03-01, in local time
+func ParseDate(text string) (time.Time, error) {
+	date, err := time.ParseInLocation(DATE_LAYOUT, text, time.Local)
+	if err != nil {
+		return date, errors.New("Invalid date \"" + text + "\". Use e.g. 2024-03-01")
+	}
+	return date, nil
+}
+
+// Export format for a file name, e.g. csv for report.csv. "" if the extension
+// isn't a known format
+func FormatOf(path string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	for _, format := range EXPORT_FORMATS {
+		if ext == format {
+			return format
+		}
+	}
+	return ""
+}
+
+// Write entries in one of EXPORT_FORMATS
+func Export(w io.Writer, format string, entries []Entry) error {
+	switch format {
+	case FORMAT_CSV:
+		return writeCSV(w, entries)
+	case FORMAT_JSON:
+		return writeJSON(w, entries)
+	case FORMAT_ICS:
+		return writeICS(w, entries, time.Now())
+	}
+	return errors.New(
+		"Unknown format \"" + format + "\". Use one of " + strings.Join(EXPORT_FORMATS, ", "),
+	)
+}
+
+// Title of a phase, e.g. "Work: Write the report" or "Short break"
+func (self Entry) Summary() string {
+	title := map[string]string{
+		PHASE_WORK:        "Work",
+		PHASE_SHORT_BREAK: "Short break",
+		PHASE_LONG_BREAK:  "Long break",
+	}[self.Phase]
+	if title == "" {
+		title = self.Phase
+	}
+	if self.Task != "" {
+		title += ": " + self.Task
+	}
+	return title
+}
+
+// Times are local so they read well in a spreadsheet, and lengths are minutes
+func writeCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"date", "start", "end", "phase", "task", "planned_minutes", "actual_minutes", "completed",
+		"abandoned", "internal_interruptions", "external_interruptions", "paused_minutes", "waiting_minutes",
+		"away_minutes",
+	})
+	for _, entry := range entries {
+		internal, external := entry.InterruptionCounts()
+		writer.Write([]string{
+			entry.Start.Local().Format(DATE_LAYOUT),
+			entry.Start.Local().Format("15:04:05"),
+			entry.End.Local().Format("15:04:05"),
+			entry.Phase,
+			entry.Task,
+			minutes(entry.Planned),
+			minutes(entry.Actual),
+			strconv.FormatBool(entry.Completed),
+			strconv.FormatBool(entry.Abandoned),
+			strconv.Itoa(internal),
+			strconv.Itoa(external),
+			minutes(entry.Paused),
+			minutes(entry.Waiting),
+			minutes(entry.Away),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func minutes(seconds int) string {
+	return strconv.FormatFloat(float64(seconds)/60, 'f', -1, 64)
+}
+
+func writeJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// One VEVENT per phase. now is the time the events are stamped with
+func writeICS(w io.Writer, entries []Entry, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + ICS_PRODID,
+		"CALSCALE:GREGORIAN",
+	}
+	for _, entry := range entries {
+		result := "completed"
+		if entry.Abandoned {
+			result = "abandoned"
+		} else if !entry.Completed {
+			result = "unfinished"
+		}
+		description := "Planned " + timer.ShortTimeString(entry.Planned) +
+			", actual " + timer.ShortTimeString(entry.Actual) + ", " + result
+		if entry.Paused > 0 {
+			description += ", paused " + timer.ShortTimeString(entry.Paused)
+		}
+		if entry.Away > 0 {
+			description += ", away " + timer.ShortTimeString(entry.Away)
+		}
+		if internal, external := entry.InterruptionCounts(); internal+external > 0 {
+			description += "\nInterruptions: " + strconv.Itoa(internal) + " internal, " +
+				strconv.Itoa(external) + " external"
+		}
+		for _, interruption := range entry.Interruptions {
+			if interruption.Note != "" {
+				description += "\n" + interruption.Time.Local().Format("15:04") + " " +
+					interruption.Kind + ": " + interruption.Note
+			}
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+entry.Start.UTC().Format(ICS_TIME)+"-"+entry.Phase+"@pomodoro-cli",
+			"DTSTAMP:"+now.UTC().Format(ICS_TIME),
+			"DTSTART:"+entry.Start.UTC().Format(ICS_TIME),
+			"DTEND:"+entry.End.UTC().Format(ICS_TIME),
+			"SUMMARY:"+escapeICS(entry.Summary()),
+			"DESCRIPTION:"+escapeICS(description),
+			"CATEGORIES:"+entry.Phase,
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+	for _, line := range lines {
+		_, err := io.WriteString(w, foldICS(line)+"\r\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "")
+
+func escapeICS(text string) string {
+	return icsEscaper.Replace(text)
+}
+
+// Split a line into ICS_LINE_LENGTH byte pieces, each continued with a space,
+// without cutting a character in half
+func foldICS(line string) string {
+	folded := ""
+	limit := ICS_LINE_LENGTH
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isCharStart(line[cut]) {
+			cut--
+		}
+		folded += line[:cut] + "\r\n "
+		line = line[cut:]
+		// The leading space counts towards the length
+		limit = ICS_LINE_LENGTH - 1
+	}
+	return folded + line
+}
+
+func isCharStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportEntries() []Entry {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	return []Entry{
+		{
+			Phase: PHASE_WORK, Start: start, End: start.Add(25 * time.Minute),
+			Planned: 1500, Actual: 1500, Completed: true, Task: "Report, part 1; draft",
+			Interruptions: []Interruption{
+				{Kind: INTERRUPTION_INTERNAL, Time: start.Add(time.Minute), Note: "email"},
+			},
+		},
+		{
+			Phase: PHASE_SHORT_BREAK, Start: start.Add(25 * time.Minute), End: start.Add(28 * time.Minute),
+			Planned: 300, Actual: 90, Paused: 120, Waiting: 30,
+		},
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := Export(&buf, FORMAT_CSV, exportEntries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatal("Expected a header and 2 rows. Got:", lines)
+	}
+	if !strings.HasSuffix(lines[1], `work,"Report, part 1; draft",25,25,true,false,1,0,0,0,0`) {
+		t.Error("Expected the task quoted and lengths in minutes. Got:", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "short_break,,5,1.5,false,false,0,0,2,0.5,0") {
+		t.Error("Expected an unfinished break. Got:", lines[2])
+	}
+}
+
+func TestExportICS(t *testing.T) {
+	var buf bytes.Buffer
+	entries := exportEntries()
+	entries[1].Task = strings.Repeat("🍅", 30)
+	now := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	err := writeICS(&buf, entries, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20240301T090000Z\r\nDTEND:20240301T092500Z\r\n",
+		`SUMMARY:Work: Report\, part 1\; draft` + "\r\n",
+		`DESCRIPTION:Planned 5m\, actual 1m30s\, unfinished\, paused 2m` + "\r\n",
+		`\nInterruptions: 1 internal\, 0 external\n`,
+		` internal: email`,
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(strings.ReplaceAll(text, "\r\n ", ""), expected) {
+			t.Error("Expected", expected, "Got:", text)
+		}
+	}
+	if !strings.Contains(text, "\r\n 🍅") {
+		t.Error("Expected the long summary to be folded. Got:", text)
+	}
+	if strings.Count(text, "BEGIN:VEVENT") != 2 {
+		t.Error("Expected an event per entry. Got:", text)
+	}
+	for _, line := range strings.Split(text, "\r\n") {
+		if len(line) > ICS_LINE_LENGTH {
+			t.Error("Expected lines of at most 75 bytes. Got:", len(line), line)
+		}
+		if line != strings.ToValidUTF8(line, "") {
+			t.Error("Expected folding to keep characters whole. Got:", line)
+		}
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	err := Export(&bytes.Buffer{}, "xml", nil)
+	if err == nil || err.Error() != `Unknown format "xml". Use one of csv, json, ics` {
+		t.Error("Expected an unknown format error. Got:", err)
+	}
+	if FormatOf("report.ICS") != FORMAT_ICS || FormatOf("report.txt") != "" {
+		t.Error("Expected the format from the extension. Got:", FormatOf("report.ICS"), FormatOf("report.txt"))
+	}
+}
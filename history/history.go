@@ -0,0 +1,170 @@
+// Record of finished phases. Each phase is stored as one line of JSON so a
+// crash loses at most the phase in progress and the file can be read by other
+// tools.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HISTORY_DIR  = "pomodoro"
+	HISTORY_NAME = "history.jsonl"
+)
+
+const (
+	PHASE_WORK        = "work"
+	PHASE_SHORT_BREAK = "short_break"
+	PHASE_LONG_BREAK  = "long_break"
+)
+
+const (
+	// Caused by yourself, e.g. remembering something else to do
+	INTERRUPTION_INTERNAL = "internal"
+	// Caused by someone else, e.g. a call or a colleague
+	INTERRUPTION_EXTERNAL = "external"
+)
+
+type Interruption struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+	Note string    `json:"note,omitempty"`
+}
+
+type Entry struct {
+	Phase string    `json:"phase"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Length the phase was set to in seconds
+	Planned int `json:"planned"`
+	// Seconds the timer ran for, not counting pauses
+	Actual int `json:"actual"`
+	// Seconds the phase was paused for, and spent waiting before it was
+	// started
+	Paused  int `json:"paused,omitempty"`
+	Waiting int `json:"waiting,omitempty"`
+	// False if the phase was skipped or the session ended during it
+	Completed bool `json:"completed"`
+	// Seconds nobody was at the computer that were taken off Actual. They are
+	// counted in Paused
+	Away int `json:"away,omitempty"`
+	// True if the pomodoro was voided. It doesn't count as a pomodoro
+	Abandoned bool   `json:"abandoned,omitempty"`
+	Task      string `json:"task,omitempty"`
+	// Logged during a work phase
+	Interruptions []Interruption `json:"interruptions,omitempty"`
+}
+
+func (self Entry) InterruptionCounts() (internal, external int) {
+	return CountInterruptions(self.Interruptions)
+}
+
+func CountInterruptions(interruptions []Interruption) (internal, external int) {
+	for _, interruption := range interruptions {
+		if interruption.Kind == INTERRUPTION_INTERNAL {
+			internal++
+		} else {
+			external++
+		}
+	}
+	return
+}
+
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// $XDG_DATA_HOME/pomodoro/history.jsonl, or ~/.local/share/pomodoro/history.jsonl
+// when XDG_DATA_HOME is not set
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return HISTORY_NAME
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, HISTORY_DIR, HISTORY_NAME)
+}
+
+func (self *Store) Path() string {
+	return self.path
+}
+
+// Add an entry to the end of the file. The file and its directory are created
+// if needed
+func (self *Store) Append(entry Entry) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(self.path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(self.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Entries that started at or after from and before to, oldest first. A zero
+// time leaves that end open. A missing file has no entries
+func (self *Store) Read(from, to time.Time) ([]Entry, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	file, err := os.Open(self.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return entries, errors.New(
+				self.path + ":" + strconv.Itoa(line) + ": " + err.Error(),
+			)
+		}
+		if entry.startedBetween(from, to) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// A zero time leaves that end open
+func (self Entry) startedBetween(from, to time.Time) bool {
+	if !from.IsZero() && self.Start.Before(from) {
+		return false
+	}
+	return to.IsZero() || self.Start.Before(to)
+}
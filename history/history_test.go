@@ -0,0 +1,77 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "data", HISTORY_NAME))
+	entries, err := store.Read(time.Time{}, time.Time{})
+	if err != nil || len(entries) != 0 {
+		t.Error("Expected no entries before anything is recorded. Got:", entries, err)
+	}
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	for i, phase := range []string{PHASE_WORK, PHASE_SHORT_BREAK, PHASE_WORK} {
+		err := store.Append(Entry{
+			Phase:     phase,
+			Start:     start.Add(time.Duration(i) * time.Hour),
+			End:       start.Add(time.Duration(i)*time.Hour + 25*time.Minute),
+			Planned:   25 * 60,
+			Actual:    25 * 60,
+			Completed: true,
+			Task:      "Write tests",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err = store.Read(start.Add(time.Hour), time.Time{})
+	if err != nil || len(entries) != 2 || entries[0].Phase != PHASE_SHORT_BREAK {
+		t.Error("Expected the last 2 entries. Got:", entries, err)
+	}
+	entries, _ = store.Read(time.Time{}, start.Add(time.Hour))
+	if len(entries) != 1 || entries[0].Task != "Write tests" || !entries[0].Start.Equal(start) {
+		t.Error("Expected the first entry. Got:", entries)
+	}
+}
+
+func TestReadReportsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_NAME)
+	os.WriteFile(path, []byte("{\"phase\": \"work\"}\n\nnot json\n"), 0644)
+	entries, err := NewStore(path).Read(time.Time{}, time.Time{})
+	if err == nil || err.Error()[:len(path)+2] != path+":3" {
+		t.Error("Expected an error on line 3. Got:", err)
+	}
+	if len(entries) != 1 {
+		t.Error("Expected the entry before the bad line. Got:", entries)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize([]Entry{
+		{Phase: PHASE_WORK, Actual: 1500, Completed: true},
+		{Phase: PHASE_SHORT_BREAK, Actual: 300, Completed: true},
+		{Phase: PHASE_WORK, Actual: 600},
+		{Phase: PHASE_LONG_BREAK, Actual: 900, Completed: true},
+		{Phase: PHASE_WORK, Actual: 300, Abandoned: true},
+	})
+	expected := Summary{Pomodoros: 1, Unfinished: 1, Abandoned: 1, WorkTime: 2400, BreakTime: 1200}
+	if summary != expected {
+		t.Error("Expected", expected, "Got:", summary)
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	// A Sunday
+	sunday := time.Date(2024, 3, 10, 18, 30, 0, 0, time.Local)
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	if got := StartOfWeek(sunday); !got.Equal(monday) {
+		t.Error("Expected", monday, "Got:", got)
+	}
+	if got := StartOfWeek(monday); !got.Equal(monday) {
+		t.Error("Expected", monday, "Got:", got)
+	}
+}
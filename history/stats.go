@@ -0,0 +1,63 @@
+package history
+
+import "time"
+
+// Totals for a range of entries
+type Summary struct {
+	// Completed work phases
+	Pomodoros int
+	// Work phases that were skipped or cut short
+	Unfinished int
+	// Work phases that were voided
+	Abandoned int
+	// Seconds spent working and on breaks
+	WorkTime  int
+	BreakTime int
+}
+
+func Summarize(entries []Entry) (summary Summary) {
+	for _, entry := range entries {
+		summary.Add(entry)
+	}
+	return
+}
+
+func (self *Summary) Add(entry Entry) {
+	if entry.Phase != PHASE_WORK {
+		self.BreakTime += entry.Actual
+		return
+	}
+	self.WorkTime += entry.Actual
+	switch {
+	case entry.Completed:
+		self.Pomodoros++
+	case entry.Abandoned:
+		self.Abandoned++
+	default:
+		self.Unfinished++
+	}
+}
+
+// Midnight at the start of the day t is in
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Midnight at the start of the Monday of the week t is in
+func StartOfWeek(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return StartOfDay(t).AddDate(0, 0, -daysSinceMonday)
+}
+
+// Entries that started at or after from and before to. A zero time leaves that
+// end open
+func Between(entries []Entry, from, to time.Time) []Entry {
+	result := []Entry{}
+	for _, entry := range entries {
+		if entry.startedBetween(from, to) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -0,0 +1,210 @@
+// Keys and mouse buttons that actions can be bound to
+package keys
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// A key press or a mouse button. Button is set for mouse input, otherwise
+// Code, Char and Mod describe the key. Char is only used when Code is
+// tcell.KeyRune
+type Key struct {
+	Code   tcell.Key
+	Char   rune
+	Mod    tcell.ModMask
+	Button tcell.ButtonMask
+}
+
+var mouseButtons = map[string]tcell.ButtonMask{
+	"mouseleft":   tcell.Button1,
+	"mouseright":  tcell.Button2,
+	"mousemiddle": tcell.Button3,
+	"wheelup":     tcell.WheelUp,
+	"wheeldown":   tcell.WheelDown,
+}
+
+var modifiers = map[string]tcell.ModMask{
+	"ctrl":  tcell.ModCtrl,
+	"alt":   tcell.ModAlt,
+	"shift": tcell.ModShift,
+	"meta":  tcell.ModMeta,
+}
+
+// Lowercased key names accepted by ParseKey
+var keyCodes = func() map[string]tcell.Key {
+	codes := map[string]tcell.Key{}
+	for code, name := range tcell.KeyNames {
+		// Ctrl combinations are written as modifiers, e.g. Ctrl+S
+		if strings.HasPrefix(name, "Ctrl-") {
+			continue
+		}
+		codes[strings.ToLower(name)] = code
+	}
+	// Most terminals send DEL for backspace
+	codes["backspace"] = tcell.KeyBackspace2
+	codes["escape"] = tcell.KeyEscape
+	return codes
+}()
+
+// Actions mapped to the keys and mouse buttons that trigger them
+type Map map[string][]Key
+
+// Action bound to the key or "" if there is none
+func (self Map) Action(key Key) string {
+	for action, bound := range self {
+		for _, k := range bound {
+			if k == key {
+				return action
+			}
+		}
+	}
+	return ""
+}
+
+// Display names of the keys bound to an action, e.g. 'q'/Esc
+func (self Map) Names(action string) string {
+	names := make([]string, 0, len(self[action]))
+	for _, key := range self[action] {
+		names = append(names, key.String())
+	}
+	return strings.Join(names, "/")
+}
+
+func RuneKey(r rune) Key {
+	return Key{Code: tcell.KeyRune, Char: r}
+}
+
+func MouseKey(button tcell.ButtonMask) Key {
+	return Key{Button: button}
+}
+
+// Parse a key such as "s", "Space", "Enter", "Up", "F5", "Ctrl+S",
+// "Alt+Shift+Left" or "MouseLeft"
+func ParseKey(name string) (Key, error) {
+	invalid := errors.New("Invalid key \"" + name + "\"")
+	if name == "" {
+		return Key{}, invalid
+	}
+	parts := strings.Split(name, "+")
+	base := parts[len(parts)-1]
+	mods := parts[:len(parts)-1]
+	if base == "" && len(parts) > 1 {
+		// The '+' key itself, e.g. "+" or "Ctrl++"
+		base = "+"
+		mods = parts[:len(parts)-2]
+	}
+	var mod tcell.ModMask
+	for _, m := range mods {
+		mask, ok := modifiers[strings.ToLower(m)]
+		if !ok {
+			return Key{}, invalid
+		}
+		mod |= mask
+	}
+	lower := strings.ToLower(base)
+	if button, ok := mouseButtons[lower]; ok {
+		if mod != tcell.ModNone {
+			return Key{}, invalid
+		}
+		return MouseKey(button), nil
+	}
+	if lower == "space" {
+		return normalize(Key{Code: tcell.KeyRune, Char: ' ', Mod: mod}), nil
+	}
+	if code, ok := keyCodes[lower]; ok {
+		return normalize(Key{Code: code, Mod: mod}), nil
+	}
+	if utf8.RuneCountInString(base) != 1 {
+		return Key{}, invalid
+	}
+	r, _ := utf8.DecodeRuneInString(base)
+	if r == utf8.RuneError {
+		return Key{}, invalid
+	}
+	if mod&tcell.ModCtrl != 0 {
+		lr := []rune(strings.ToLower(base))[0]
+		if lr < 'a' || lr > 'z' {
+			return Key{}, invalid
+		}
+		return normalize(Key{Code: tcell.KeyCtrlA + tcell.Key(lr-'a'), Mod: mod}), nil
+	}
+	return normalize(Key{Code: tcell.KeyRune, Char: r, Mod: mod}), nil
+}
+
+// Drop modifiers that are already implied by the key so that keys parsed from
+// the config compare equal to keys read from the terminal
+func normalize(key Key) Key {
+	if key.Code == tcell.KeyRune {
+		key.Mod &^= tcell.ModShift
+	} else if key.Code <= tcell.KeyUS || key.Code == tcell.KeyDEL {
+		key.Mod &^= tcell.ModCtrl
+	}
+	return key
+}
+
+func FromEvent(ev *tcell.EventKey) Key {
+	if ev.Key() == tcell.KeyRune {
+		return normalize(Key{Code: tcell.KeyRune, Char: ev.Rune(), Mod: ev.Modifiers()})
+	}
+	return normalize(Key{Code: ev.Key(), Mod: ev.Modifiers()})
+}
+
+// Display name of the key as used in help text, e.g. 'q', Esc or Ctrl+S
+func (self Key) String() string {
+	if self.Button != 0 {
+		for name, button := range mouseButtons {
+			if button == self.Button {
+				return displayName(name)
+			}
+		}
+		return "Mouse"
+	}
+	prefix := ""
+	for _, m := range []string{"ctrl", "alt", "meta", "shift"} {
+		if self.Mod&modifiers[m] != 0 {
+			prefix += displayName(m) + "+"
+		}
+	}
+	switch {
+	case self.Code == tcell.KeyRune && self.Char == ' ':
+		return prefix + "Space"
+	case self.Code == tcell.KeyRune:
+		if prefix == "" && self.Char == '\'' {
+			return `"'"`
+		}
+		if prefix == "" {
+			return "'" + string(self.Char) + "'"
+		}
+		return prefix + string(self.Char)
+	case self.Code >= tcell.KeyCtrlA && self.Code <= tcell.KeyCtrlZ &&
+		tcell.KeyNames[self.Code] == "Ctrl-"+string(rune('A'+self.Code-tcell.KeyCtrlA)):
+		return "Ctrl+" + prefix + string(rune('A'+self.Code-tcell.KeyCtrlA))
+	case self.Code == tcell.KeyBackspace2:
+		return prefix + "Backspace"
+	default:
+		if name, ok := tcell.KeyNames[self.Code]; ok {
+			return prefix + name
+		}
+		return prefix + "?"
+	}
+}
+
+func displayName(name string) string {
+	switch name {
+	case "mouseleft":
+		return "MouseLeft"
+	case "mouseright":
+		return "MouseRight"
+	case "mousemiddle":
+		return "MouseMiddle"
+	case "wheelup":
+		return "WheelUp"
+	case "wheeldown":
+		return "WheelDown"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
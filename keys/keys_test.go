@@ -0,0 +1,85 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseKey(t *testing.T) {
+	cases := map[string]Key{
+		"s":         RuneKey('s'),
+		"Space":     RuneKey(' '),
+		"Enter":     {Code: tcell.KeyEnter},
+		"esc":       {Code: tcell.KeyEscape},
+		"Up":        {Code: tcell.KeyUp},
+		"Shift+Up":  {Code: tcell.KeyUp, Mod: tcell.ModShift},
+		"Ctrl+S":    {Code: tcell.KeyCtrlS},
+		"Alt+x":     {Code: tcell.KeyRune, Char: 'x', Mod: tcell.ModAlt},
+		"+":         RuneKey('+'),
+		"MouseLeft": MouseKey(tcell.Button1),
+	}
+	for name, want := range cases {
+		got, err := ParseKey(name)
+		if err != nil {
+			t.Error("Expected", name, "to be valid. Got:", err)
+		}
+		if got != want {
+			t.Error("Expected", name, "to parse to", want, "Got:", got)
+		}
+	}
+	for _, name := range []string{"", "sk", "Hyper+s", "Ctrl+1", "Ctrl+MouseLeft"} {
+		if _, err := ParseKey(name); err == nil {
+			t.Error("Expected", name, "to be invalid")
+		}
+	}
+}
+
+func TestEventKeyMatchesParsedKey(t *testing.T) {
+	cases := map[string]*tcell.EventKey{
+		"s":      tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+		"Enter":  tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone),
+		"Ctrl+S": tcell.NewEventKey(tcell.KeyCtrlS, 0, tcell.ModCtrl),
+		"Space":  tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone),
+	}
+	for name, ev := range cases {
+		key, _ := ParseKey(name)
+		if FromEvent(ev) != key {
+			t.Error("Expected event to match", name, "Got:", FromEvent(ev))
+		}
+	}
+}
+
+func TestKeyString(t *testing.T) {
+	cases := map[string]string{
+		"s":         "'s'",
+		"'":         `"'"`,
+		"space":     "Space",
+		"esc":       "Esc",
+		"ctrl+s":    "Ctrl+S",
+		"alt+x":     "Alt+x",
+		"MouseLeft": "MouseLeft",
+	}
+	for name, want := range cases {
+		key, _ := ParseKey(name)
+		if key.String() != want {
+			t.Error("Expected", want, "Got:", key.String())
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	bindings := Map{
+		"start": {RuneKey('s'), RuneKey(' ')},
+		"quit":  {RuneKey('q'), {Code: tcell.KeyEscape}},
+	}
+	if bindings.Action(RuneKey(' ')) != "start" {
+		t.Error("Expected Space to start. Got:", bindings.Action(RuneKey(' ')))
+	}
+	if bindings.Action(RuneKey('x')) != "" {
+		t.Error("Expected 'x' to not be bound. Got:", bindings.Action(RuneKey('x')))
+	}
+	if bindings.Names("quit") != "'q'/Esc" {
+		t.Error("Expected 'q'/Esc. Got:", bindings.Names("quit"))
+	}
+}
@@ -0,0 +1,209 @@
+// Plain text frontend used when there is no terminal to draw on
+package lineui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"strings"
+	"sync"
+)
+
+// Implements both view.Renderer and view.InputSource. Commands are read one
+// per line as either an action name or a bound key. With overwrite the status
+// line is redrawn in place on every render, otherwise a new line is only
+// printed when the state changes. The session ends once it is done.
+type LineUI struct {
+	mu          sync.Mutex
+	in          io.Reader
+	out         io.Writer
+	bindings    keys.Map
+	overwrite   bool
+	prevState   timer.TimerState
+	prevOverlay string
+	prevMessage string
+	prevLen     int
+	finished    chan struct{}
+	finishOnce  sync.Once
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewLineUI(in io.Reader, out io.Writer, bindings keys.Map, overwrite bool) *LineUI {
+	return &LineUI{
+		in:        in,
+		out:       out,
+		bindings:  bindings,
+		overwrite: overwrite,
+		prevState: -1,
+		finished:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (self *LineUI) Render(model view.Model) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if model.Overlay != "" {
+		if model.Overlay != self.prevOverlay {
+			self.endLine()
+			fmt.Fprintln(self.out, model.Overlay)
+			self.prevOverlay = model.Overlay
+		}
+		return
+	}
+	if self.prevOverlay != "" {
+		self.prevOverlay = ""
+		// Print the hints again once the overlay is closed
+		self.prevState = -1
+	}
+	if model.Message != self.prevMessage {
+		if model.Message != "" {
+			self.endLine()
+			fmt.Fprintln(self.out, model.Message)
+		}
+		self.prevMessage = model.Message
+	}
+	line := statusLine(model)
+	if model.State != self.prevState {
+		self.endLine()
+		self.prevState = model.State
+		if model.State == timer.DONE {
+			fmt.Fprintln(self.out, line)
+			self.finishOnce.Do(func() {
+				close(self.finished)
+			})
+			return
+		}
+		if hints := model.KeyText(); hints != "" {
+			fmt.Fprintln(self.out, strings.ReplaceAll(hints, "\n", ", "))
+		}
+		if !self.overwrite {
+			fmt.Fprintln(self.out, line)
+		}
+	}
+	if self.overwrite {
+		padding := strings.Repeat(" ", maxInt(self.prevLen-len([]rune(line)), 0))
+		fmt.Fprint(self.out, "\r"+line+padding)
+		self.prevLen = len([]rune(line))
+	}
+}
+
+// Move past a status line that is being redrawn in place
+func (self *LineUI) endLine() {
+	if self.overwrite && self.prevLen > 0 {
+		fmt.Fprintln(self.out)
+	}
+	self.prevLen = 0
+}
+
+func statusLine(model view.Model) string {
+	text := model.TimerText()
+	if model.State == timer.DONE {
+		return text
+	}
+	if model.Task != "" {
+		text += " | Task: " + model.Task
+	}
+	if model.Activity != "" {
+		text += " | Try: " + model.Activity
+	}
+	if model.ProjectedEnd != "" {
+		text += " | " + model.ProjectedEnd
+	}
+	text += " | " + strings.TrimSpace(model.PomodoroString())
+	if breaks := strings.TrimSpace(model.BreakString()); breaks != "" {
+		text += " | " + breaks
+	}
+	if interruptions := model.InterruptionText(); interruptions != "" {
+		text += " | " + interruptions
+	}
+	if goals := model.GoalText(); goals != "" {
+		text += " | " + goals
+	}
+	return text
+}
+
+// Read commands until the session is done or Close is called. Input is read
+// in the background so a closed reader doesn't end the session. Empty lines
+// are passed on too, since they answer a prompt
+func (self *LineUI) Listen(inputs chan<- view.Input) {
+	commands := make(chan view.Input)
+	go func() {
+		scanner := bufio.NewScanner(self.in)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			input := view.Input{}
+			if line != "" {
+				input = ParseCommand(line, self.currentBindings())
+			}
+			input.FromLine = true
+			input.Line = line
+			select {
+			case commands <- input:
+			case <-self.done:
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case input := <-commands:
+			select {
+			case inputs <- input:
+			case <-self.done:
+				return
+			}
+		case <-self.finished:
+			return
+		case <-self.done:
+			return
+		}
+	}
+}
+
+func (self *LineUI) SetBindings(bindings keys.Map) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.bindings = bindings
+}
+
+func (self *LineUI) currentBindings() keys.Map {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.bindings
+}
+
+func (self *LineUI) Close() {
+	self.closeOnce.Do(func() {
+		close(self.done)
+		self.mu.Lock()
+		self.endLine()
+		self.mu.Unlock()
+	})
+}
+
+// Map a command to an input. A command is either the name of an action,
+// optionally followed by text, or a key such as "s" or "Space". Anything else
+// is passed on as text
+func ParseCommand(command string, bindings keys.Map) view.Input {
+	action, rest, _ := strings.Cut(command, " ")
+	if _, ok := bindings[strings.ToLower(action)]; ok {
+		return view.Input{Action: strings.ToLower(action), Text: strings.TrimSpace(rest)}
+	}
+	key, err := keys.ParseKey(command)
+	if err != nil {
+		return view.Input{Text: command}
+	}
+	return view.Input{Action: bindings.Action(key), Key: key}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package lineui
+
+import (
+	"bytes"
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"strings"
+	"testing"
+)
+
+func testBindings() keys.Map {
+	return keys.Map{
+		"start":   {keys.RuneKey('s')},
+		"skip":    {keys.RuneKey('k')},
+		"reset":   {keys.RuneKey('r')},
+		"restart": {keys.RuneKey('R')},
+		"quit":    {keys.RuneKey('q')},
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	bindings := testBindings()
+	cases := map[string]string{
+		"s":     "start",
+		"Start": "start",
+		"k":     "skip",
+		"R":     "restart",
+		"r":     "reset",
+		"x":     "",
+		"jump":  "",
+	}
+	for command, want := range cases {
+		if got := ParseCommand(command, bindings).Action; got != want {
+			t.Error("Expected", command, "to be", want, "Got:", got)
+		}
+	}
+	if ParseCommand("y", bindings).Key != keys.RuneKey('y') {
+		t.Error("Expected unbound keys to still be parsed")
+	}
+	if input := ParseCommand("skip this one", bindings); input.Action != "skip" || input.Text != "this one" {
+		t.Error("Expected the text after the action. Got:", input)
+	}
+	if input := ParseCommand("phone call", bindings); input.Action != "" || input.Text != "phone call" {
+		t.Error("Expected other commands to be passed on as text. Got:", input)
+	}
+}
+
+func TestListen(t *testing.T) {
+	ui := NewLineUI(strings.NewReader("s\n\n  k \nquit\n"), &bytes.Buffer{}, testBindings(), false)
+	inputs := make(chan view.Input)
+	go ui.Listen(inputs)
+	actions := []string{}
+	for i := 0; i < 4; i++ {
+		input := <-inputs
+		if !input.FromLine {
+			t.Error("Expected every input to come from a line. Got:", input)
+		}
+		actions = append(actions, input.Action)
+	}
+	ui.Close()
+	if strings.Join(actions, ",") != "start,,skip,quit" {
+		t.Error("Expected actions start,,skip,quit Got:", actions)
+	}
+}
+
+func TestRenderPrintsOnTransition(t *testing.T) {
+	out := &bytes.Buffer{}
+	ui := NewLineUI(strings.NewReader(""), out, testBindings(), false)
+	model := view.Model{
+		State:         timer.WORK,
+		Remaining:     60,
+		MaxIterations: 2,
+		Keys:          []view.KeyHint{{Keys: "'k'", Label: "Skip"}},
+	}
+	ui.Render(model)
+	model.Remaining = 59
+	ui.Render(model)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Error("Expected hints and one status line. Got:", lines)
+	}
+	model.State = timer.DONE
+	ui.Render(model)
+	select {
+	case <-ui.finished:
+	default:
+		t.Error("Expected line ui to finish once the session is done")
+	}
+}
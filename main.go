@@ -3,54 +3,203 @@ package main
 import (
 	"fmt"
 	"os"
+	"pomodoro/history"
 	"pomodoro/runner"
 	"strings"
-	"sync"
 
 	"github.com/akamensky/argparse"
 )
 
-const CONFIG_PATH = "go_pomodoro_config.json"
-
 func main() {
 	parser := argparse.NewParser(
 		"pomodoro",
 		"A Simple and Customisable CLI Pomodoro timer",
 	)
-	var workTime *int = parser.Int("w", "work", &argparse.Options{Required: false, Help: "Work time in minutes"})
-	var breakTime *int = parser.Int("b", "break", &argparse.Options{Required: false, Help: "Break time in minutes"})
-	var longBreakTime *int = parser.Int("l", "long-break", &argparse.Options{Required: false, Help: "Long break time in minutes"})
-	var longBreakInterval *int = parser.Int("i", "interval", &argparse.Options{Required: false, Help: "Number of pomodoros before a long break"})
-	var totalPomodoros *int = parser.Int("n", "number", &argparse.Options{Required: false, Help: "Total number of pomodoros"})
-	var autoStart *bool = parser.Flag("a", "auto-start", &argparse.Options{Required: false, Help: "When a timer finishes, auto start the next timer"})
-	var testMode *bool = parser.Flag("t", "test", &argparse.Options{Required: false, Help: "Test mode, run through all timers in seconds"})
-	parser.Parse(os.Args)
-	var errs []error
-	cfg, errs := runner.NewConfig(CONFIG_PATH)
-	for _, err := range errs {
-		fmt.Println(err)
-	}
-	if len(errs) > 0 {
-		fmt.Println("Press Enter to continue or q to exit")
-		var input string
-		fmt.Scanln(&input)
-		if strings.ToLower(input) == "q" {
-			os.Exit(0)
+	var configPath *string = parser.String("c", "config", &argparse.Options{Required: false, Help: "Path to the config file"})
+	var profile *string = parser.String("P", "profile", &argparse.Options{Required: false, Help: "Name of the config profile to use"})
+	start := parser.NewCommand("start", "Start a session. Used when no command is given")
+	config := parser.NewCommand("config", "Manage the config file")
+	check := config.NewCommand("check", "Check the config file for errors without starting a session")
+	initConfig := config.NewCommand("init", "Write a config file with the default values")
+	var force *bool = initConfig.Flag("f", "force", &argparse.Options{Required: false, Help: "Replace an existing config file"})
+	show := config.NewCommand("show", "Print the config file")
+	var effective *bool = show.Flag("e", "effective", &argparse.Options{Required: false, Help: "Print the values in use and where each one comes from"})
+	get := config.NewCommand("get", "Print a config value")
+	var getKey *string = get.StringPositional(&argparse.Options{Help: "Key, e.g. work_time or key_bindings.start"})
+	set := config.NewCommand("set", "Change a config value and keep the rest of the file as it is")
+	var setKey *string = set.StringPositional(&argparse.Options{Help: "Key, e.g. work_time or key_bindings.start"})
+	var setValue *string = set.StringPositional(&argparse.Options{Help: "Value, e.g. 50m or s,Space"})
+	plan := parser.NewCommand("plan", "Show how many pomodoros fit before a time, and start them")
+	startFlags := addSessionFlags(start)
+	planFlags := addSessionFlags(plan)
+	showFlags := addTimerFlags(show)
+	checkFlags := addTimerFlags(check)
+	status := parser.NewCommand("status", "Print what the running session is doing")
+	var statusJSON *bool = status.Flag("j", "json", &argparse.Options{Required: false, Help: "Print the status as JSON"})
+	ctl := parser.NewCommand("ctl", "Control the running session: "+strings.Join(runner.CONTROL_ACTIONS, ", "))
+	var ctlAction *string = ctl.StringPositional(&argparse.Options{Help: "Action, e.g. pause or skip"})
+	taskCommand := parser.NewCommand("task", "Print or change the task of the running session")
+	var taskText *string = taskCommand.StringPositional(&argparse.Options{Help: "What you are working on"})
+	var clearTask *bool = taskCommand.Flag("", "clear", &argparse.Options{Required: false, Help: "Remove the task"})
+	stats := parser.NewCommand("stats", "Print totals for today and this week from the history")
+	var days *int = stats.Int("d", "days", &argparse.Options{Required: false, Help: "Print a total for each of the last number of days instead"})
+	historyCommand := parser.NewCommand("history", "Print the latest recorded phases")
+	var limit *int = historyCommand.Int("n", "number", &argparse.Options{Required: false, Default: 20, Help: "Number of phases to print"})
+	var all *bool = historyCommand.Flag("a", "all", &argparse.Options{Required: false, Help: "Print every recorded phase"})
+	export := parser.NewCommand("export", "Write the history as CSV, JSON or iCalendar")
+	var output *string = export.String("o", "output", &argparse.Options{Required: false, Help: "File to write to instead of stdout"})
+	var format *string = export.Selector("f", "format", history.EXPORT_FORMATS, &argparse.Options{Required: false, Help: "csv, json or ics. Picked from the extension of --output, otherwise json"})
+	var from *string = export.String("", "from", &argparse.Options{Required: false, Help: "First day to export, e.g. 2024-03-01"})
+	var to *string = export.String("", "to", &argparse.Options{Required: false, Help: "Last day to export, e.g. 2024-03-31"})
+	if len(os.Args) > 1 && (os.Args[1] == "-h" || os.Args[1] == "--help") {
+		fmt.Print(parser.Usage(nil))
+		os.Exit(0)
+	}
+	err := parser.Parse(withDefaultCommand(os.Args))
+	if err != nil {
+		fmt.Print(parser.Usage(err))
+		os.Exit(1)
+	}
+	path := runner.FindConfigPath(*configPath)
+	switch {
+	case check.Happened():
+		os.Exit(checkConfig(path, *profile, checkFlags))
+	case initConfig.Happened():
+		os.Exit(report(runner.InitConfig(path, *force), "Wrote "+path))
+	case show.Happened() && *effective:
+		os.Exit(showEffective(path, *profile, showFlags))
+	case show.Happened():
+		os.Exit(showConfig(path))
+	case get.Happened():
+		os.Exit(getValue(path, *profile, *getKey))
+	case set.Happened():
+		if *setKey == "" || *setValue == "" {
+			fmt.Print(set.Usage("Expected a key and a value"))
+			os.Exit(1)
 		}
+		os.Exit(report(runner.SetConfigValue(path, *setKey, *setValue), "Set "+*setKey+" in "+path))
+	case status.Happened():
+		os.Exit(printStatus(*statusJSON))
+	case ctl.Happened():
+		os.Exit(control(*ctlAction))
+	case taskCommand.Happened():
+		os.Exit(changeTask(*taskText, *clearTask))
+	case stats.Happened():
+		os.Exit(printStats(path, *profile, *days))
+	case historyCommand.Happened():
+		if *all {
+			*limit = 0
+		}
+		os.Exit(printHistory(path, *profile, *limit))
+	case export.Happened():
+		os.Exit(exportHistory(path, *profile, exportOptions{
+			format: *format, output: *output, from: *from, to: *to,
+		}))
+	case plan.Happened():
+		os.Exit(startSession(path, *profile, planFlags, true))
 	}
-	cfg.ReadArgs(
-		*workTime,
-		*breakTime,
-		*longBreakTime,
-		*longBreakInterval,
-		*totalPomodoros,
-		*autoStart,
+	os.Exit(startSession(path, *profile, startFlags, false))
+}
+
+// Flags that override config values for a session
+type timerFlags struct {
+	workTime          *string
+	breakTime         *string
+	longBreakTime     *string
+	longBreakInterval *int
+	totalPomodoros    *int
+	autoStart         *bool
+	testMode          *bool
+}
+
+func addTimerFlags(command *argparse.Command) timerFlags {
+	return timerFlags{
+		workTime:          command.String("w", "work", &argparse.Options{Required: false, Help: "Work time, e.g. 25m, 90s or 1h15m. Plain numbers are minutes"}),
+		breakTime:         command.String("b", "break", &argparse.Options{Required: false, Help: "Break time, e.g. 5m or 90s"}),
+		longBreakTime:     command.String("l", "long-break", &argparse.Options{Required: false, Help: "Long break time, e.g. 15m"}),
+		longBreakInterval: command.Int("i", "interval", &argparse.Options{Required: false, Help: "Number of pomodoros before a long break"}),
+		totalPomodoros:    command.Int("n", "number", &argparse.Options{Required: false, Help: "Total number of pomodoros"}),
+		autoStart:         command.Flag("a", "auto-start", &argparse.Options{Required: false, Help: "When a timer finishes, auto start the next timer"}),
+		testMode:          command.Flag("t", "test", &argparse.Options{Required: false, Help: "Test mode, run through all timers in seconds"}),
+	}
+}
+
+func (self timerFlags) apply(cfg *runner.Config) error {
+	err := cfg.ReadArgs(
+		*self.workTime,
+		*self.breakTime,
+		*self.longBreakTime,
+		*self.longBreakInterval,
+		*self.totalPomodoros,
+		*self.autoStart,
 	)
-	if *testMode {
+	if err != nil {
+		return err
+	}
+	if *self.testMode {
 		cfg.TestMode()
 	}
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go runner.Run(&wg, &cfg)
-	wg.Wait()
+	return nil
+}
+
+// Flags for starting a session
+type sessionFlags struct {
+	timerFlags
+	task     *string
+	at       *string
+	until    *string
+	duration *string
+}
+
+func addSessionFlags(command *argparse.Command) sessionFlags {
+	return sessionFlags{
+		timerFlags: addTimerFlags(command),
+		task:       command.String("T", "task", &argparse.Options{Required: false, Help: "What you are working on"}),
+		at:         command.String("", "at", &argparse.Options{Required: false, Help: "Start the first pomodoro at a time like 09:00"}),
+		until:      command.String("", "until", &argparse.Options{Required: false, Help: "Fit as many pomodoros as possible before a time like 17:00"}),
+		duration:   command.String("", "for", &argparse.Options{Required: false, Help: "Fit as many pomodoros as possible in a time like 3h"}),
+	}
+}
+
+// Run the start command when the arguments don't begin with a command, so
+// `pomodoro -w 50` still works. argparse only finds a command that comes
+// first, so -c and -P before it are moved after it
+func withDefaultCommand(args []string) []string {
+	rootFlags := []string{}
+	i := 1
+	for ; i < len(args); i++ {
+		switch arg := args[i]; {
+		case (arg == "-c" || arg == "--config" || arg == "-P" || arg == "--profile") &&
+			i+1 < len(args):
+			rootFlags = append(rootFlags, arg, args[i+1])
+			i++
+			continue
+		case strings.HasPrefix(arg, "--config=") || strings.HasPrefix(arg, "--profile="):
+			rootFlags = append(rootFlags, arg)
+			continue
+		}
+		break
+	}
+	rest := args[i:]
+	if len(rest) == 0 || strings.HasPrefix(rest[0], "-") {
+		rest = append([]string{"start"}, rest...)
+	}
+	result := append([]string{args[0]}, rest...)
+	return append(result, rootFlags...)
+}
+
+// Print the error or the message on success. Returns the exit code
+func report(err error, message string) int {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, strings.TrimRight(err.Error(), "\n"))
+		return 1
+	}
+	fmt.Println(message)
+	return 0
+}
+
+func printErrors(errs []error) {
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, strings.TrimRight(err.Error(), "\n"))
+	}
 }
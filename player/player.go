@@ -2,26 +2,56 @@ package player
 
 import (
 	"log"
+	"math"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
 	"github.com/faiface/beep/mp3"
 	"github.com/faiface/beep/speaker"
 )
 
 type Player struct {
+	// Guards the paths and the volume, which can change while a session runs
+	mu             *sync.Mutex
 	workSoundPath  string
 	breakSoundPath string
+	// Percent of the volume of the files
+	volume int
 }
 
 func NewPlayer(workSoundPath, breakSoundPath string) (p Player) {
+	p.mu = &sync.Mutex{}
 	p.workSoundPath = workSoundPath
 	p.breakSoundPath = breakSoundPath
+	p.volume = 100
 	return
 }
 
-func playSound(soundPath string) error {
+// Use other sound files from the next sound on
+func (self *Player) SetSoundPaths(workSoundPath, breakSoundPath string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.workSoundPath = workSoundPath
+	self.breakSoundPath = breakSoundPath
+}
+
+// Play from the next sound on at percent of the volume of the files
+func (self *Player) SetVolume(percent int) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.volume = percent
+}
+
+func (self *Player) Volume() int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.volume
+}
+
+func playSound(soundPath string, volume int) error {
 	f, err := os.Open(soundPath)
 	if err != nil {
 		return err
@@ -33,7 +63,14 @@ func playSound(soundPath string) error {
 	defer streamer.Close()
 	speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/10))
 	done := make(chan bool)
-	speaker.Play(beep.Seq(streamer, beep.Callback(func() {
+	// Halving the volume is a step of -1 with base 2
+	quieter := &effects.Volume{
+		Streamer: streamer,
+		Base:     2,
+		Volume:   math.Log2(float64(volume) / 100),
+		Silent:   volume <= 0,
+	}
+	speaker.Play(beep.Seq(quieter, beep.Callback(func() {
 		done <- true
 	})))
 	<-done
@@ -41,15 +78,23 @@ func playSound(soundPath string) error {
 }
 
 func (self *Player) PlayBreak() {
-	if self.breakSoundPath == "" {
+	self.mu.Lock()
+	path := self.breakSoundPath
+	volume := self.volume
+	self.mu.Unlock()
+	if path == "" {
 		return
 	}
-	playSound(self.breakSoundPath)
+	playSound(path, volume)
 }
 
 func (self *Player) PlayWork() {
-	if self.workSoundPath == "" {
+	self.mu.Lock()
+	path := self.workSoundPath
+	volume := self.volume
+	self.mu.Unlock()
+	if path == "" {
 		return
 	}
-	playSound(self.workSoundPath)
+	playSound(path, volume)
 }
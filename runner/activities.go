@@ -0,0 +1,173 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"pomodoro/history"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How break activities are picked. Either way each one is suggested once
+// before any is suggested again
+const (
+	ACTIVITY_ORDER_RANDOM      = "random"
+	ACTIVITY_ORDER_ROUND_ROBIN = "round_robin"
+)
+
+var ACTIVITY_ORDERS = []string{ACTIVITY_ORDER_RANDOM, ACTIVITY_ORDER_ROUND_ROBIN}
+
+// Headers in an activities file for the lines that follow. Lines before any
+// header are for both kinds of break
+const (
+	ACTIVITIES_SHORT_HEADER = "[short]"
+	ACTIVITIES_LONG_HEADER  = "[long]"
+)
+
+var (
+	DEFAULT_SHORT_BREAK_ACTIVITIES = []string{
+		"Stretch", "Drink some water", "Look out of a window", "Take a few deep breaths",
+	}
+	DEFAULT_LONG_BREAK_ACTIVITIES = []string{
+		"Go for a walk", "Make a cup of tea", "Have a snack", "Tidy your desk",
+	}
+)
+
+// Activities left to suggest in the current round
+type activityDeck struct {
+	activities []string
+	left       []string
+	random     bool
+}
+
+func newActivityDeck(activities []string, order string) *activityDeck {
+	return &activityDeck{
+		activities: activities,
+		random:     order == ACTIVITY_ORDER_RANDOM,
+	}
+}
+
+// The next activity to suggest, or "" if there are none
+func (self *activityDeck) next(rng *rand.Rand) string {
+	if len(self.activities) == 0 {
+		return ""
+	}
+	if len(self.left) == 0 {
+		self.left = append([]string{}, self.activities...)
+		if self.random {
+			rng.Shuffle(len(self.left), func(i, j int) {
+				self.left[i], self.left[j] = self.left[j], self.left[i]
+			})
+		}
+	}
+	activity := self.left[0]
+	self.left = self.left[1:]
+	return activity
+}
+
+// The activities file with a relative path resolved, or "" if none is set
+func (self *Config) ActivitiesPath() string {
+	if self.ActivitiesFile == "" || filepath.IsAbs(self.ActivitiesFile) {
+		return self.ActivitiesFile
+	}
+	return filepath.Join(filepath.Dir(self.path), self.ActivitiesFile)
+}
+
+// Activities for short and long breaks from the config and the activities
+// file
+func (self *Config) BreakActivities() (short, long []string, err error) {
+	short = append(short, self.ShortBreakActivities...)
+	long = append(long, self.LongBreakActivities...)
+	path := self.ActivitiesPath()
+	if path == "" {
+		return short, long, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return short, long, err
+	}
+	defer file.Close()
+	header := ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+		case text == ACTIVITIES_SHORT_HEADER || text == ACTIVITIES_LONG_HEADER:
+			header = text
+		case strings.HasPrefix(text, "["):
+			return short, long, errors.New(
+				path + ":" + strconv.Itoa(line) + ": expected " +
+					ACTIVITIES_SHORT_HEADER + " or " + ACTIVITIES_LONG_HEADER + ", got " + text,
+			)
+		default:
+			if header != ACTIVITIES_LONG_HEADER {
+				short = append(short, text)
+			}
+			if header != ACTIVITIES_SHORT_HEADER {
+				long = append(long, text)
+			}
+		}
+	}
+	return short, long, scanner.Err()
+}
+
+// Build the decks from the config. A deck keeps its place when its
+// activities didn't change. Must be called with the lock held
+func (self *session) loadActivities() {
+	if self.rng == nil {
+		self.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	short, long, err := self.cfg.BreakActivities()
+	if err != nil {
+		self.notify("Failed to read break activities: " + err.Error())
+	}
+	if self.activities == nil {
+		self.activities = map[string]*activityDeck{}
+	}
+	for phase, activities := range map[string][]string{
+		history.PHASE_SHORT_BREAK: short,
+		history.PHASE_LONG_BREAK:  long,
+	} {
+		deck := self.activities[phase]
+		if deck == nil || !equalStrings(deck.activities, activities) ||
+			deck.random != (self.cfg.ActivityOrder == ACTIVITY_ORDER_RANDOM) {
+			self.activities[phase] = newActivityDeck(activities, self.cfg.ActivityOrder)
+		}
+	}
+}
+
+// Pick what to do during the break that just started. Must be called with
+// the lock held
+func (self *session) suggestActivity(phase string) {
+	self.activity = ""
+	deck := self.activities[phase]
+	if deck == nil {
+		return
+	}
+	self.activity = deck.next(self.rng)
+	if self.activity == "" {
+		return
+	}
+	if phase == history.PHASE_LONG_BREAK {
+		self.notify("Long break: " + self.activity)
+	} else {
+		self.notify("Break: " + self.activity)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
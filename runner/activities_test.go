@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"pomodoro/history"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestActivityDeckRandomHasNoRepeats(t *testing.T) {
+	deck := newActivityDeck([]string{"a", "b", "c"}, ACTIVITY_ORDER_RANDOM)
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 3; round++ {
+		picked := []string{deck.next(rng), deck.next(rng), deck.next(rng)}
+		sort.Strings(picked)
+		if strings.Join(picked, "") != "abc" {
+			t.Error("Expected each activity once per round. Got:", picked)
+		}
+	}
+}
+
+func TestActivityDeckRoundRobin(t *testing.T) {
+	deck := newActivityDeck([]string{"a", "b"}, ACTIVITY_ORDER_ROUND_ROBIN)
+	picked := ""
+	for i := 0; i < 5; i++ {
+		picked += deck.next(nil)
+	}
+	if picked != "ababa" {
+		t.Error("Expected the activities in order. Got:", picked)
+	}
+	if empty := newActivityDeck(nil, ACTIVITY_ORDER_RANDOM); empty.next(nil) != "" {
+		t.Error("Expected no activity from an empty list")
+	}
+}
+
+func TestActivitiesFile(t *testing.T) {
+	dir := t.TempDir()
+	cfg := defaultConfig(filepath.Join(dir, "config.json"))
+	cfg.ShortBreakActivities = []string{"Stretch"}
+	cfg.LongBreakActivities = nil
+	cfg.ActivitiesFile = "activities.txt"
+	os.WriteFile(filepath.Join(dir, "activities.txt"), []byte(
+		"# Both kinds\nDrink some water\n\n[short]\nRoll your shoulders\n[long]\nGo for a walk\n",
+	), 0644)
+	short, long, err := cfg.BreakActivities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(short, ",") != "Stretch,Drink some water,Roll your shoulders" {
+		t.Error("Expected the short break activities. Got:", short)
+	}
+	if strings.Join(long, ",") != "Drink some water,Go for a walk" {
+		t.Error("Expected the long break activities. Got:", long)
+	}
+	os.WriteFile(filepath.Join(dir, "activities.txt"), []byte("[lunch]\nEat\n"), 0644)
+	_, _, err = cfg.BreakActivities()
+	if err == nil || !strings.Contains(err.Error(), "activities.txt:1: expected [short] or [long]") {
+		t.Error("Expected an unknown header to be reported. Got:", err)
+	}
+}
+
+func TestActivitySuggestedWhenBreakStarts(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.ShortBreakActivities = []string{"Stretch", "Drink some water"}
+	s.cfg.ActivityOrder = ACTIVITY_ORDER_ROUND_ROBIN
+	s.loadActivities()
+	s.act(ACTION_START)
+	s.tickAndRecord(4)
+	if s.model().Activity != "" {
+		t.Error("Expected no activity before the break starts. Got:", s.model().Activity)
+	}
+	s.act(ACTION_START)
+	if s.model().Activity != "Stretch" || s.currentMessage() != "Break: Stretch" {
+		t.Error("Expected the first activity. Got:", s.model().Activity, s.currentMessage())
+	}
+	s.act(ACTION_SKIP)
+	if s.model().Activity != "" || s.current.phase != "" {
+		t.Error("Expected the activity to be cleared after the break. Got:", s.model().Activity)
+	}
+	s.act(ACTION_START)
+	s.act(ACTION_SKIP)
+	s.act(ACTION_START)
+	if s.current.phase != history.PHASE_LONG_BREAK || s.model().Activity == "" {
+		t.Error("Expected a long break activity. Got:", s.current.phase, s.model().Activity)
+	}
+}
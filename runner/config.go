@@ -5,6 +5,12 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"pomodoro/keys"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -15,6 +21,7 @@ const (
 	DEFAULT_TOTAL_POMODOROS     = 8
 	DEFAULT_LONG_BREAK_INTERVAL = 4
 	DEFAULT_SOUND_PATH          = "bell.mp3"
+	DEFAULT_VOLUME              = 100
 	DEFAULT_WORK_CHAR           = "🍅"
 	DEFAULT_BREAK_CHAR          = "🍌"
 	DEFAULT_EMPTY_CHAR          = "➖"
@@ -29,49 +36,124 @@ const (
 )
 
 type Config struct {
-	WorkSoundPath     string `json:"work_mp3"`
-	BreakSoundPath    string `json:"break_mp3"`
-	WorkTime          int    `json:"work_time"`
-	BreakTime         int    `json:"break_time"`
-	LongBreakTime     int    `json:"long_break_time"`
-	LongBreakInterval int    `json:"long_break_interval"`
-	AutoStart         bool   `json:"auto_start"`
-	TotalPomodoros    int    `json:"total_pomodoros"`
-	WorkChar          string `json:"pomodoro_char"`
-	BreakChar         string `json:"break_char"`
-	EmptyChar         string `json:"empty_char"`
-}
-
-func NewConfig(configPath string) (cfg Config, errs []error) {
-	cfg = Config{
-		WorkSoundPath:     DEFAULT_SOUND_PATH,
-		BreakSoundPath:    DEFAULT_SOUND_PATH,
-		WorkTime:          DEFAULT_WORK_TIME,
-		BreakTime:         DEFAULT_BREAK_TIME,
-		LongBreakTime:     DEFAULT_LONG_BREAK_TIME,
-		LongBreakInterval: DEFAULT_LONG_BREAK_INTERVAL,
-		AutoStart:         false,
-		TotalPomodoros:    DEFAULT_TOTAL_POMODOROS,
-		WorkChar:          DEFAULT_WORK_CHAR,
-		BreakChar:         DEFAULT_BREAK_CHAR,
-		EmptyChar:         DEFAULT_EMPTY_CHAR,
-	}
-	err := cfg.createOrRead(configPath)
-	if err != nil {
-		errs = append(errs, err)
+	WorkSoundPath     string   `json:"work_mp3"`
+	BreakSoundPath    string   `json:"break_mp3"`
+	Volume            int      `json:"volume"`
+	WorkTime          Duration `json:"work_time"`
+	BreakTime         Duration `json:"break_time"`
+	LongBreakTime     Duration `json:"long_break_time"`
+	LongBreakInterval int      `json:"long_break_interval"`
+	AutoStart         bool     `json:"auto_start"`
+	TotalPomodoros    int      `json:"total_pomodoros"`
+	CountSkippedWork  bool     `json:"count_skipped_work"`
+	WorkChar          string   `json:"pomodoro_char"`
+	BreakChar         string   `json:"break_char"`
+	EmptyChar         string   `json:"empty_char"`
+	HistoryFile       string   `json:"history_file"`
+	DailyGoal         int      `json:"daily_goal"`
+	WeeklyGoal        int      `json:"weekly_goal"`
+	DailyFocusGoal    Duration `json:"daily_focus_goal"`
+	WeeklyFocusGoal   Duration `json:"weekly_focus_goal"`
+	GoalCommand       string   `json:"goal_command"`
+	// Suggested at the start of each break
+	ShortBreakActivities []string `json:"short_break_activities"`
+	LongBreakActivities  []string `json:"long_break_activities"`
+	ActivitiesFile       string   `json:"activities_file"`
+	ActivityOrder        string   `json:"activity_order"`
+	IdleTimeout          Duration `json:"idle_timeout"`
+	IdleCommand          string   `json:"idle_command"`
+	// Times of day like "09:00-17:30". "" when not set
+	WorkingHours   string             `json:"working_hours"`
+	Lunch          string             `json:"lunch"`
+	KeyBindings    KeyBindings        `json:"key_bindings"`
+	DefaultProfile string             `json:"default_profile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles,omitempty"`
+	Task           string             `json:"-"`
+	// When the first pomodoro starts, zero to start straight away
+	StartAt time.Time `json:"-"`
+	// End the session was planned to finish by, zero when it wasn't planned
+	PlanTarget time.Time `json:"-"`
+	path       string
+	profile    string
+	// Values read from the file before the profile was applied
+	base *Config
+	// Where each value that isn't a default came from, keyed like
+	// "work_time" or "key_bindings.start"
+	sources map[string]string
+	// Command line values, applied again when the file is reloaded
+	args     *configArgs
+	testMode bool
+}
+
+type configArgs struct {
+	workTime, breakTime, longBreakTime string
+	longBreakInterval, totalPomodoros  int
+	autoStart                          bool
+}
+
+// Read the config at configPath and apply the named profile on top of it. An
+// empty profile name means the default profile, if there is one
+func NewConfig(configPath, profile string) (Config, []error) {
+	return loadConfig(configPath, profile, true)
+}
+
+// Like NewConfig but a missing file is read as empty instead of created
+func ReadConfig(configPath, profile string) (Config, []error) {
+	return loadConfig(configPath, profile, false)
+}
+
+func loadConfig(configPath, profile string, create bool) (cfg Config, errs []error) {
+	cfg = defaultConfig(configPath)
+	if create || fileExists(configPath) {
+		errs = append(errs, cfg.createOrRead(configPath)...)
+	}
+	if profile == "" {
+		profile = os.Getenv(envName("default_profile"))
 	}
-	err = cfg.validateSoundPaths(configPath)
+	err := cfg.applyProfile(profile, configPath)
 	if err != nil {
 		errs = append(errs, err)
 	}
-	err = cfg.validateValues(configPath)
+	errs = append(errs, cfg.readEnv(os.Environ())...)
+	errs = append(errs, cfg.validateValues(configPath)...)
+	err = cfg.validateKeyBindings(configPath)
 	if err != nil {
 		errs = append(errs, err)
 	}
 	return
 }
 
+func defaultConfig(configPath string) Config {
+	return Config{
+		WorkSoundPath:        DEFAULT_SOUND_PATH,
+		BreakSoundPath:       DEFAULT_SOUND_PATH,
+		Volume:               DEFAULT_VOLUME,
+		WorkTime:             DEFAULT_WORK_TIME,
+		BreakTime:            DEFAULT_BREAK_TIME,
+		LongBreakTime:        DEFAULT_LONG_BREAK_TIME,
+		LongBreakInterval:    DEFAULT_LONG_BREAK_INTERVAL,
+		AutoStart:            false,
+		TotalPomodoros:       DEFAULT_TOTAL_POMODOROS,
+		CountSkippedWork:     true,
+		WorkChar:             DEFAULT_WORK_CHAR,
+		BreakChar:            DEFAULT_BREAK_CHAR,
+		EmptyChar:            DEFAULT_EMPTY_CHAR,
+		ShortBreakActivities: append([]string{}, DEFAULT_SHORT_BREAK_ACTIVITIES...),
+		LongBreakActivities:  append([]string{}, DEFAULT_LONG_BREAK_ACTIVITIES...),
+		ActivityOrder:        ACTIVITY_ORDER_RANDOM,
+		KeyBindings:          DefaultKeyBindings(),
+		path:                 configPath,
+		sources:              map[string]string{},
+	}
+}
+
 func (self *Config) TestMode() {
+	self.testMode = true
+	for _, key := range []string{
+		"work_time", "break_time", "long_break_time", "long_break_interval", "total_pomodoros",
+	} {
+		self.setSource(key, SOURCE_ARGS)
+	}
 	self.WorkTime = TEST_WORK_TIME
 	self.BreakTime = TEST_BREAK_TIME
 	self.LongBreakTime = TEST_LONG_BREAK_TIME
@@ -79,71 +161,272 @@ func (self *Config) TestMode() {
 	self.TotalPomodoros = TEST_TOTAL_POMODOROS
 }
 
-func (self *Config) createOrRead(configPath string) (err error) {
-	file, err := os.Open(configPath)
+// Invalid values in the file are reported and keep their defaults
+func (self *Config) createOrRead(configPath string) (errs []error) {
+	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			os.Create(configPath)
-			self.toMinutes()
-			jsonCfg, err := json.MarshalIndent(self, "", "  ")
-			if err != nil {
-				self.toSeconds()
-				return err
+			err = os.MkdirAll(filepath.Dir(configPath), 0755)
+			if err == nil {
+				err = self.write(configPath)
 			}
-			err = ioutil.WriteFile(configPath, jsonCfg, 0644)
-			if err != nil {
-				self.toSeconds()
-				return err
-			}
-		} else {
-			return err
 		}
+		if err != nil {
+			return []error{err}
+		}
+		return nil
 	}
-	defer file.Close()
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&self)
-	if err == nil {
-		self.toSeconds()
+	return self.decode(configPath, data)
+}
+
+// Read the contents of a config file over the current values
+func (self *Config) decode(configPath string, data []byte) []error {
+	valid, errs := validateConfig(configPath, data)
+	if valid == nil {
+		return errs
 	}
-	return err
+	err := json.Unmarshal(valid, self)
+	if err != nil {
+		return append(errs, err)
+	}
+	self.setSources(valid, func(string) string { return configPath })
+	return errs
 }
 
+// Environment variables override the file and the profile
+func (self *Config) readEnv(environ []string) []error {
+	valid, errs := readEnv(environ)
+	if valid == nil {
+		return errs
+	}
+	err := json.Unmarshal(valid, self)
+	if err != nil {
+		return append(errs, err)
+	}
+	self.setSources(valid, func(key string) string {
+		if action := strings.TrimPrefix(key, "key_bindings."); action != key {
+			return ENV_KEY_PREFIX + strings.ToUpper(action)
+		}
+		return envName(key)
+	})
+	return errs
+}
+
+// Write a config file with the default values. An existing file is only
+// replaced with force
+func InitConfig(configPath string, force bool) error {
+	if fileExists(configPath) && !force {
+		return errors.New("Config file " + configPath + " already exists. Use --force to replace it")
+	}
+	err := os.MkdirAll(filepath.Dir(configPath), 0755)
+	if err != nil {
+		return err
+	}
+	cfg := defaultConfig(configPath)
+	return cfg.write(configPath)
+}
+
+// Check a config file without creating it or starting a session
+func CheckConfig(configPath, profile string) []error {
+	if !fileExists(configPath) {
+		return []error{errors.New("Config file " + configPath + " not found")}
+	}
+	cfg, errs := NewConfig(configPath, profile)
+	if err := cfg.CheckSounds(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Read the file again with the same profile and command line values. The
+// file has to exist so a deleted config isn't replaced with the defaults
+func (self *Config) reload() (cfg Config, errs []error) {
+	if !fileExists(self.path) {
+		return *self, []error{errors.New("Config file " + self.path + " not found")}
+	}
+	cfg, errs = NewConfig(self.path, self.profile)
+	if err := cfg.CheckSounds(); err != nil {
+		errs = append(errs, err)
+	}
+	if args := self.args; args != nil {
+		err := cfg.ReadArgs(
+			args.workTime, args.breakTime, args.longBreakTime,
+			args.longBreakInterval, args.totalPomodoros, args.autoStart,
+		)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if self.testMode {
+		cfg.TestMode()
+	}
+	cfg.keepSession(self)
+	return
+}
+
+// Copy the values that only live as long as the session, like the task, from
+// another config
+func (self *Config) keepSession(from *Config) {
+	self.Task = from.Task
+	self.StartAt = from.StartAt
+	self.PlanTarget = from.PlanTarget
+}
+
+func (self *Config) write(configPath string) (err error) {
+	data, err := encodeConfig(configPath, self)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, data, 0644)
+}
+
+// Change the values of keys like "work_time" in the file the config was read
+// from and leave the rest of it as it is. With a profile in use only that
+// profile is changed
+func (self *Config) SaveValues(values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fileKey := key
+		if self.profile != "" {
+			fileKey = joinKey(joinKey("profiles", self.profile), key)
+		}
+		err := SetConfigValue(self.path, fileKey, values[key])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *Config) Path() string {
+	return self.path
+}
+
+// Times are durations like "25m" or "90s", or plain numbers of minutes. Empty
+// strings and 0 are ignored like unset flags, anything else out of range is an
+// error
 func (self *Config) ReadArgs(
-	workTime, breakTime, longBreakTime, longBreakInterval, totalPomodoros int,
+	workTime, breakTime, longBreakTime string,
+	longBreakInterval, totalPomodoros int,
 	autoStart bool,
-) {
-	if workTime > 0 {
-		self.WorkTime = workTime * 60
+) (err error) {
+	self.args = &configArgs{
+		workTime, breakTime, longBreakTime, longBreakInterval, totalPomodoros, autoStart,
 	}
-	if breakTime > 0 {
-		self.BreakTime = breakTime * 60
+	for _, arg := range []struct {
+		key  string
+		text string
+		dest *Duration
+	}{
+		{"work_time", workTime, &self.WorkTime},
+		{"break_time", breakTime, &self.BreakTime},
+		{"long_break_time", longBreakTime, &self.LongBreakTime},
+	} {
+		if arg.text == "" || arg.text == "0" {
+			continue
+		}
+		d, err := ParseDuration(arg.text)
+		if err != nil {
+			return &ValidationError{
+				File: SOURCE_ARGS, Field: arg.key, Value: strconv.Quote(arg.text),
+				Message: durationProblem(arg.text),
+			}
+		}
+		*arg.dest = d
+		self.setSource(arg.key, SOURCE_ARGS)
 	}
-	if longBreakTime > 0 {
-		self.LongBreakTime = longBreakTime * 60
+	for _, arg := range []struct {
+		key   string
+		value int
+	}{
+		{"long_break_interval", longBreakInterval},
+		{"total_pomodoros", totalPomodoros},
+	} {
+		if arg.value < 0 {
+			return &ValidationError{
+				File: SOURCE_ARGS, Field: arg.key, Value: strconv.Itoa(arg.value),
+				Message: "must be at least 1",
+			}
+		}
 	}
 	if longBreakInterval > 0 {
 		self.LongBreakInterval = longBreakInterval
+		self.setSource("long_break_interval", SOURCE_ARGS)
 	}
 	if totalPomodoros > 0 {
 		self.TotalPomodoros = totalPomodoros
+		self.setSource("total_pomodoros", SOURCE_ARGS)
 	}
 	if autoStart {
 		self.AutoStart = autoStart
+		self.setSource("auto_start", SOURCE_ARGS)
 	}
+	return
 }
 
-func (self *Config) validateValues(configPath string) (err error) {
-	self.WorkTime = maxInt(self.WorkTime, 1)
-	self.BreakTime = maxInt(self.BreakTime, 1)
-	self.LongBreakTime = maxInt(self.LongBreakTime, 1)
-	self.LongBreakInterval = maxInt(self.LongBreakInterval, 1)
-	self.TotalPomodoros = maxInt(self.TotalPomodoros, 1)
+// Values below 1 are reported and keep their defaults. The file, the profile
+// and the environment are checked as they are read, so this only catches what
+// got past that
+func (self *Config) validateValues(configPath string) (errs []error) {
+	for _, field := range []struct {
+		key   string
+		value int
+		reset func()
+	}{
+		{"work_time", int(self.WorkTime), func() { self.WorkTime = DEFAULT_WORK_TIME }},
+		{"break_time", int(self.BreakTime), func() { self.BreakTime = DEFAULT_BREAK_TIME }},
+		{"long_break_time", int(self.LongBreakTime), func() { self.LongBreakTime = DEFAULT_LONG_BREAK_TIME }},
+		{"long_break_interval", self.LongBreakInterval, func() { self.LongBreakInterval = DEFAULT_LONG_BREAK_INTERVAL }},
+		{"total_pomodoros", self.TotalPomodoros, func() { self.TotalPomodoros = DEFAULT_TOTAL_POMODOROS }},
+	} {
+		if field.value >= 1 {
+			continue
+		}
+		file := self.sources[field.key]
+		if file == "" {
+			file = configPath
+		}
+		errs = append(errs, &ValidationError{
+			File: file, Field: field.key, Value: strconv.Itoa(field.value),
+			Message: "must be at least 1",
+		})
+		field.reset()
+		delete(self.sources, field.key)
+	}
 	self.WorkChar = readChar(self.WorkChar, DEFAULT_WORK_CHAR)
 	self.BreakChar = readChar(self.BreakChar, DEFAULT_BREAK_CHAR)
 	self.EmptyChar = readChar(self.EmptyChar, DEFAULT_EMPTY_CHAR)
 	return
 }
 
+func (self *Config) validateKeyBindings(configPath string) (err error) {
+	_, err = self.KeyBindings.Parse()
+	if err != nil {
+		self.KeyBindings = DefaultKeyBindings()
+		for _, action := range ACTIONS {
+			delete(self.sources, joinKey("key_bindings", action))
+		}
+		errMsg := err.Error() + ".\n"
+		errMsg += "Ensure the key bindings are correct in the " + configPath + " file.\n"
+		errMsg += "Defaulting to the default key bindings...\n"
+		return errors.New(errMsg)
+	}
+	return
+}
+
+// Parsed key bindings. These are always valid after NewConfig
+func (self *Config) KeyMap() keys.Map {
+	bindings, err := self.KeyBindings.Parse()
+	if err != nil {
+		bindings, _ = DefaultKeyBindings().Parse()
+	}
+	return bindings
+}
+
 func readChar(str, def string) string {
 	if len(str) == 0 {
 		return def
@@ -156,18 +439,32 @@ func readChar(str, def string) string {
 	return str
 }
 
+// Replace sound files that don't exist. Only done for a session and config
+// check, so commands that just read the config don't warn about sounds
+func (self *Config) CheckSounds() error {
+	workSoundPath, breakSoundPath := self.WorkSoundPath, self.BreakSoundPath
+	err := self.validateSoundPaths(self.path)
+	if self.WorkSoundPath != workSoundPath {
+		self.setSource("work_mp3", "replaced, "+workSoundPath+" not found")
+	}
+	if self.BreakSoundPath != breakSoundPath {
+		self.setSource("break_mp3", "replaced, "+breakSoundPath+" not found")
+	}
+	return err
+}
+
 func (self *Config) validateSoundPaths(configPath string) (err error) {
 	invalidWorkPath := false
 	invalidBreakPath := false
 	defaultExists := false
-	_, e := os.Stat(DEFAULT_SOUND_PATH)
+	_, e := os.Stat(self.soundPath(DEFAULT_SOUND_PATH))
 	defaultExists = !os.IsNotExist(e)
 	if self.WorkSoundPath != "" {
-		_, e = os.Stat(self.WorkSoundPath)
+		_, e = os.Stat(self.soundPath(self.WorkSoundPath))
 		invalidWorkPath = os.IsNotExist(e)
 	}
 	if self.BreakSoundPath != "" {
-		_, e = os.Stat(self.BreakSoundPath)
+		_, e = os.Stat(self.soundPath(self.BreakSoundPath))
 		invalidBreakPath = os.IsNotExist(e)
 	}
 	if !(invalidWorkPath && invalidBreakPath) {
@@ -203,24 +500,9 @@ func (self *Config) validateSoundPaths(configPath string) (err error) {
 	}
 }
 
-func (self *Config) toMinutes() {
-	self.WorkTime /= 60
-	self.BreakTime /= 60
-	self.LongBreakTime /= 60
-}
-
 func maxInt(a, b int) int {
 	if a > b {
 		return a
 	}
 	return b
 }
-
-func (self *Config) toSeconds() {
-	self.WorkTime = maxInt(self.WorkTime, 1)
-	self.BreakTime = maxInt(self.BreakTime, 1)
-	self.LongBreakTime = maxInt(self.LongBreakTime, 1)
-	self.WorkTime *= 60
-	self.BreakTime *= 60
-	self.LongBreakTime *= 60
-}
@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"pomodoro/history"
+	"strings"
+)
+
+const (
+	CONFIG_ENV      = "POMODORO_CONFIG"
+	CONFIG_NAME     = "go_pomodoro_config.json"
+	XDG_CONFIG_DIR  = "pomodoro"
+	XDG_CONFIG_NAME = "config.json"
+)
+
+// Pick the config file to use. In order:
+//
+//	$POMODORO_CONFIG
+//	the --config flag
+//	$XDG_CONFIG_HOME/pomodoro/config.json
+//	go_pomodoro_config.json next to the executable
+//	go_pomodoro_config.json in the working directory
+//
+// The first two are used even if the file doesn't exist yet. Otherwise the
+// first existing file is used, trying .toml, .yaml and .yml after .json in
+// each location. If there is none the config is created in the XDG config
+// directory
+func FindConfigPath(flagPath string) string {
+	if path := os.Getenv(CONFIG_ENV); path != "" {
+		return path
+	}
+	if flagPath != "" {
+		return flagPath
+	}
+	xdgPath := xdgConfigPath()
+	candidates := []string{}
+	if xdgPath != "" {
+		candidates = append(candidates, xdgPath)
+	}
+	if dir := executableDir(); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, CONFIG_NAME))
+	}
+	candidates = append(candidates, CONFIG_NAME)
+	for _, candidate := range candidates {
+		base := strings.TrimSuffix(candidate, filepath.Ext(candidate))
+		for _, ext := range CONFIG_EXTENSIONS {
+			if path := base + ext; fileExists(path) {
+				return path
+			}
+		}
+	}
+	if xdgPath == "" {
+		return CONFIG_NAME
+	}
+	return xdgPath
+}
+
+// Returns "" if neither $XDG_CONFIG_HOME nor $HOME is set
+func xdgConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, XDG_CONFIG_DIR, XDG_CONFIG_NAME)
+}
+
+// Directory of the running binary with symlinks resolved, or "" if unknown
+func executableDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return ""
+	}
+	return filepath.Dir(exe)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Relative sound paths are relative to the directory of the config file. The
+// default sound is also looked for next to the executable
+func (self *Config) soundPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	resolved := filepath.Join(filepath.Dir(self.path), path)
+	if path != DEFAULT_SOUND_PATH || fileExists(resolved) {
+		return resolved
+	}
+	if dir := executableDir(); dir != "" {
+		if exePath := filepath.Join(dir, path); fileExists(exePath) {
+			return exePath
+		}
+	}
+	return resolved
+}
+
+// The history file with a relative path resolved, or the default location
+func (self *Config) HistoryPath() string {
+	if self.HistoryFile == "" {
+		return history.DefaultPath()
+	}
+	if filepath.IsAbs(self.HistoryFile) {
+		return self.HistoryFile
+	}
+	return filepath.Join(filepath.Dir(self.path), self.HistoryFile)
+}
+
+// Sound files to play with relative paths resolved
+func (self *Config) SoundPaths() (work, brk string) {
+	return self.soundPath(self.WorkSoundPath), self.soundPath(self.BreakSoundPath)
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindConfigPath(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv(CONFIG_ENV, "")
+	xdgPath := filepath.Join(xdg, XDG_CONFIG_DIR, XDG_CONFIG_NAME)
+
+	// Nothing exists yet so the config goes in the XDG directory
+	if path := FindConfigPath(""); path != xdgPath {
+		t.Error("Expected", xdgPath, "Got:", path)
+	}
+	if path := FindConfigPath("flag.json"); path != "flag.json" {
+		t.Error("Expected the --config path. Got:", path)
+	}
+	t.Setenv(CONFIG_ENV, "env.json")
+	if path := FindConfigPath("flag.json"); path != "env.json" {
+		t.Error("Expected $POMODORO_CONFIG to come first. Got:", path)
+	}
+	t.Setenv(CONFIG_ENV, "")
+
+	cwd := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(cwd)
+	defer os.Chdir(wd)
+	os.WriteFile(CONFIG_NAME, []byte("{}"), 0644)
+	if path := FindConfigPath(""); path != CONFIG_NAME {
+		t.Error("Expected the config in the working directory. Got:", path)
+	}
+	os.MkdirAll(filepath.Dir(xdgPath), 0755)
+	os.WriteFile(xdgPath, []byte("{}"), 0644)
+	if path := FindConfigPath(""); path != xdgPath {
+		t.Error("Expected the XDG config to come before the working directory. Got:", path)
+	}
+}
+
+func TestNewConfigCreatesOnlyChosenFile(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv(CONFIG_ENV, "")
+	cwd := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(cwd)
+	defer os.Chdir(wd)
+
+	path := FindConfigPath("")
+	NewConfig(path, "")
+	if !fileExists(path) {
+		t.Error("Expected the config to be created at", path)
+	}
+	if fileExists(CONFIG_NAME) {
+		t.Error("Expected no config in the working directory")
+	}
+}
+
+func TestSoundPathRelativeToConfig(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "work.mp3"), []byte{}, 0644)
+	cfg := Config{
+		WorkSoundPath:  "work.mp3",
+		BreakSoundPath: "/abs/break.mp3",
+		path:           filepath.Join(dir, "config.json"),
+	}
+	work, brk := cfg.SoundPaths()
+	if work != filepath.Join(dir, "work.mp3") {
+		t.Error("Expected the work sound next to the config. Got:", work)
+	}
+	if brk != "/abs/break.mp3" {
+		t.Error("Expected absolute paths to be kept. Got:", brk)
+	}
+	cfg.BreakSoundPath = "work.mp3"
+	err := cfg.validateSoundPaths(cfg.path)
+	if err != nil || cfg.WorkSoundPath != "work.mp3" {
+		t.Error("Expected work.mp3 to be found next to the config. Got:", cfg.WorkSoundPath, err)
+	}
+}
+
+func TestMissingSoundsOnlyReportedWhenChecked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"work_mp3": "work.mp3", "break_mp3": "break.mp3"}`), 0644)
+	if _, errs := ReadConfig(path, ""); len(errs) > 0 {
+		t.Error("Expected reading the config not to check sounds. Got:", errs)
+	}
+	if errs := CheckConfig(path, ""); len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "Sound files") {
+		t.Error("Expected config check to report the sounds. Got:", errs)
+	}
+}
@@ -0,0 +1,243 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Requests other pomodoro processes can send to a running session
+const (
+	CONTROL_STATUS = "status"
+	CONTROL_ACTION = "action"
+	CONTROL_TASK   = "task"
+)
+
+// How long a request may take before the connection is dropped
+const CONTROL_TIMEOUT = 2 * time.Second
+
+// Actions that can be sent with pomodoro ctl. Ones that ask for confirmation
+// in the UI are done straight away since the command was typed on purpose
+var CONTROL_ACTIONS = []string{
+	ACTION_START, ACTION_PAUSE, ACTION_SKIP, ACTION_RESET, ACTION_RESTART,
+	ACTION_END, ACTION_VOID, ACTION_QUIT,
+}
+
+type ControlRequest struct {
+	Command string `json:"command"`
+	Action  string `json:"action,omitempty"`
+	Task    string `json:"task,omitempty"`
+}
+
+type ControlResponse struct {
+	Status *Status `json:"status,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// What a running session is doing
+type Status struct {
+	State string `json:"state"`
+	Phase string `json:"phase"`
+	// Seconds left in the phase
+	Remaining      int    `json:"remaining"`
+	Pomodoros      int    `json:"pomodoros"`
+	TotalPomodoros int    `json:"total_pomodoros"`
+	Task           string `json:"task,omitempty"`
+	Profile        string `json:"profile,omitempty"`
+	Config         string `json:"config"`
+	Pid            int    `json:"pid"`
+}
+
+// e.g. "Work: 12m:34s left (paused)" followed by the pomodoros and the task
+func (self Status) String() string {
+	lines := []string{}
+	switch {
+	case self.State == timer.DONE.String():
+		lines = append(lines, "Done")
+	case strings.HasSuffix(self.State, "_PAUSED"):
+		lines = append(lines, self.Phase+": "+timer.TimeString(self.Remaining)+" left (paused)")
+	case self.State == timer.SCHEDULED.String():
+		lines = append(lines, self.Phase+": starts in "+timer.TimeString(self.Remaining))
+	case strings.HasPrefix(self.State, "PRE_") || self.State == timer.STOPPED.String():
+		lines = append(lines, self.Phase+": "+timer.TimeString(self.Remaining)+" (not started)")
+	default:
+		lines = append(lines, self.Phase+": "+timer.TimeString(self.Remaining)+" left")
+	}
+	lines = append(lines, "Pomodoros: "+strconv.Itoa(self.Pomodoros)+"/"+strconv.Itoa(self.TotalPomodoros))
+	if self.Task != "" {
+		lines = append(lines, "Task: "+self.Task)
+	}
+	if self.Profile != "" {
+		lines = append(lines, "Profile: "+self.Profile)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Socket a running session listens on, in $XDG_RUNTIME_DIR or the temp
+// directory
+func ControlPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "pomodoro-"+strconv.Itoa(os.Getuid())+".sock")
+}
+
+// Send a request to the session listening on path
+func SendControl(path string, request ControlRequest) (ControlResponse, error) {
+	var response ControlResponse
+	conn, err := net.DialTimeout("unix", path, CONTROL_TIMEOUT)
+	if err != nil {
+		return response, errors.New("No session is running")
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(CONTROL_TIMEOUT))
+	err = json.NewEncoder(conn).Encode(request)
+	if err != nil {
+		return response, err
+	}
+	err = json.NewDecoder(conn).Decode(&response)
+	if err != nil {
+		return response, err
+	}
+	if response.Error != "" {
+		return response, errors.New(response.Error)
+	}
+	return response, nil
+}
+
+// Take requests from other processes until the session is quit. Fails if
+// another session is already listening on path
+func (self *session) serveControl(path string) error {
+	listener, err := listenControl(path)
+	if err != nil {
+		return err
+	}
+	// Closed by quit
+	self.controlListener = listener
+	self.controlPath = path
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go self.handleControl(conn)
+		}
+	}()
+	return nil
+}
+
+func listenControl(path string) (net.Listener, error) {
+	listener, err := net.Listen("unix", path)
+	if err == nil {
+		return listener, nil
+	}
+	if conn, dialErr := net.Dial("unix", path); dialErr == nil {
+		conn.Close()
+		return nil, errors.New("Another session is already running")
+	}
+	// Left behind by a session that didn't quit cleanly
+	os.Remove(path)
+	return net.Listen("unix", path)
+}
+
+func (self *session) handleControl(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(CONTROL_TIMEOUT))
+	var request ControlRequest
+	response := ControlResponse{}
+	err := json.NewDecoder(conn).Decode(&request)
+	if err != nil {
+		response.Error = err.Error()
+	} else {
+		response = self.control(request)
+	}
+	json.NewEncoder(conn).Encode(response)
+}
+
+// Must be called without the lock held
+func (self *session) control(request ControlRequest) ControlResponse {
+	if request.Command == CONTROL_ACTION && request.Action == ACTION_QUIT {
+		self.mu.Lock()
+		status := self.status()
+		self.mu.Unlock()
+		self.quit()
+		return ControlResponse{Status: &status}
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.activeAt = time.Now()
+	switch request.Command {
+	case CONTROL_STATUS:
+	case CONTROL_ACTION:
+		err := self.controlAction(request.Action)
+		if err != nil {
+			return ControlResponse{Error: err.Error()}
+		}
+	case CONTROL_TASK:
+		self.cfg.Task = request.Task
+	default:
+		return ControlResponse{Error: "Unknown command \"" + request.Command + "\""}
+	}
+	self.record()
+	self.render()
+	status := self.status()
+	return ControlResponse{Status: &status}
+}
+
+// Must be called with the lock held
+func (self *session) controlAction(action string) error {
+	known := false
+	for _, name := range CONTROL_ACTIONS {
+		known = known || name == action
+	}
+	if !known {
+		return errors.New(
+			"Unknown action \"" + action + "\". Use one of " + strings.Join(CONTROL_ACTIONS, ", "),
+		)
+	}
+	state := self.tmr.TimerState()
+	response, ok := self.responses[state][action]
+	if !ok {
+		return errors.New(
+			"Can't " + action + " while " + strings.ToLower(strings.ReplaceAll(state.String(), "_", " ")),
+		)
+	}
+	switch action {
+	case ACTION_RESET:
+		self.tmr.ResetPhase()
+	case ACTION_RESTART:
+		self.restart()
+	case ACTION_END:
+		self.tmr.Stop()
+	case ACTION_VOID:
+		self.void()
+	default:
+		response()
+	}
+	return nil
+}
+
+// Must be called with the lock held
+func (self *session) status() Status {
+	state := self.tmr.TimerState()
+	return Status{
+		State:          state.String(),
+		Phase:          view.Model{State: state}.Phase(),
+		Remaining:      remaining(self.tmr),
+		Pomodoros:      self.tmr.WorkIter(),
+		TotalPomodoros: self.tmr.MaxWorkIter(),
+		Task:           self.cfg.Task,
+		Profile:        self.cfg.Profile(),
+		Config:         self.cfg.Path(),
+		Pid:            os.Getpid(),
+	}
+}
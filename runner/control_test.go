@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"path/filepath"
+	"pomodoro/timer"
+	"testing"
+)
+
+func TestControl(t *testing.T) {
+	s := newRecordSession(t)
+	path := filepath.Join(t.TempDir(), "control.sock")
+	_, err := SendControl(path, ControlRequest{Command: CONTROL_STATUS})
+	if err == nil || err.Error() != "No session is running" {
+		t.Error("Expected no session to be running. Got:", err)
+	}
+	err = s.serveControl(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := SendControl(path, ControlRequest{Command: CONTROL_STATUS})
+	if err != nil || response.Status.State != timer.STOPPED.String() || response.Status.Remaining != 3 {
+		t.Error("Expected a stopped 3s timer. Got:", response.Status, err)
+	}
+	_, err = SendControl(path, ControlRequest{Command: CONTROL_ACTION, Action: ACTION_PAUSE})
+	if err == nil || err.Error() != "Can't pause while stopped" {
+		t.Error("Expected pause to be refused. Got:", err)
+	}
+	response, err = SendControl(path, ControlRequest{Command: CONTROL_ACTION, Action: ACTION_START})
+	if err != nil || response.Status.State != timer.WORK.String() {
+		t.Error("Expected the timer to start. Got:", response.Status, err)
+	}
+	// Done straight away without asking for confirmation
+	response, err = SendControl(path, ControlRequest{Command: CONTROL_ACTION, Action: ACTION_END})
+	if err != nil || response.Status.State != timer.DONE.String() || s.overlay != NO_OVERLAY {
+		t.Error("Expected the session to end. Got:", response.Status, err)
+	}
+	response, err = SendControl(path, ControlRequest{Command: CONTROL_TASK, Task: "Review"})
+	if err != nil || response.Status.Task != "Review" || s.cfg.Task != "Review" {
+		t.Error("Expected the task to change. Got:", response.Status, err)
+	}
+	other := newRecordSession(t)
+	err = other.serveControl(path)
+	if err == nil || err.Error() != "Another session is already running" {
+		t.Error("Expected the socket to be in use. Got:", err)
+	}
+	_, err = SendControl(path, ControlRequest{Command: CONTROL_ACTION, Action: ACTION_QUIT})
+	if err != nil {
+		t.Error("Expected quit to succeed. Got:", err)
+	}
+	select {
+	case <-s.done:
+	default:
+		t.Error("Expected the session to be quit")
+	}
+	if fileExists(path) {
+		t.Error("Expected the socket to be removed on quit")
+	}
+}
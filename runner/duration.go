@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"pomodoro/timer"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Length of a timer in seconds. Written to config files like "25m" or "1h15m"
+// and read from either that or a plain number of minutes
+type Duration int
+
+// Parse a duration like "25m", "90s" or "1h15m". A plain number is minutes.
+// The result has to be a whole number of seconds and at least 1s
+func ParseDuration(text string) (Duration, error) {
+	text = strings.TrimSpace(text)
+	invalid := errors.New(
+		"Invalid duration \"" + text + "\". Use e.g. \"25m\", \"90s\" or \"1h15m\"",
+	)
+	var d time.Duration
+	if minutes, err := strconv.ParseFloat(text, 64); err == nil {
+		d = time.Duration(minutes * float64(time.Minute))
+	} else {
+		d, err = time.ParseDuration(text)
+		if err != nil {
+			return 0, invalid
+		}
+	}
+	if d%time.Second != 0 {
+		return 0, errors.New("Duration \"" + text + "\" must be a whole number of seconds")
+	}
+	if d < time.Second {
+		return 0, errors.New("Duration \"" + text + "\" must be at least 1s")
+	}
+	return Duration(d / time.Second), nil
+}
+
+// Like ParseDuration but "0" or "0s" is also allowed, for settings that can be
+// turned off
+func ParseOptionalDuration(text string) (Duration, error) {
+	if d, err := time.ParseDuration(strings.TrimSpace(text)); err == nil && d == 0 {
+		return 0, nil
+	}
+	return ParseDuration(text)
+}
+
+// e.g. "25m", "1m30s" or "1h15m"
+func (self Duration) String() string {
+	return timer.ShortTimeString(int(self))
+}
+
+func (self Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.String())
+}
+
+func (self *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		// A plain number of minutes
+		text = string(data)
+	}
+	d, err := ParseOptionalDuration(text)
+	if err != nil {
+		return err
+	}
+	*self = d
+	return nil
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDuration(t *testing.T) {
+	for text, seconds := range map[string]Duration{
+		"25":    25 * 60,
+		"7.5":   450,
+		"25m":   25 * 60,
+		"90s":   90,
+		"1h15m": 75 * 60,
+		" 2s ":  2,
+	} {
+		d, err := ParseDuration(text)
+		if err != nil || d != seconds {
+			t.Error("Expected", text, "to be", int(seconds), "seconds. Got:", int(d), err)
+		}
+	}
+	for _, text := range []string{"", "abc", "0", "-5m", "1.5s", "500ms"} {
+		if _, err := ParseDuration(text); err == nil {
+			t.Error("Expected", text, "to be invalid")
+		}
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	for seconds, text := range map[Duration]string{
+		25 * 60: "25m",
+		90:      "1m30s",
+		75 * 60: "1h15m",
+		3600:    "1h",
+		0:       "0s",
+	} {
+		if got := seconds.String(); got != text {
+			t.Error("Expected", text, "Got:", got)
+		}
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	var times struct {
+		Old Duration `json:"old"`
+		New Duration `json:"new"`
+	}
+	err := json.Unmarshal([]byte(`{"old": 25, "new": "1m30s"}`), &times)
+	if err != nil || times.Old != 25*60 || times.New != 90 {
+		t.Error("Expected 1500 and 90 seconds. Got:", int(times.Old), int(times.New), err)
+	}
+	data, _ := json.Marshal(times)
+	if string(data) != `{"old":"25m","new":"1m30s"}` {
+		t.Error("Expected durations to be written with units. Got:", string(data))
+	}
+}
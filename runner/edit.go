@@ -0,0 +1,664 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Changes single values in the text of a config file so comments, order and
+// layout stay as they were
+type configEditor interface {
+	// Whether the file has a value for a key like "key_bindings.start"
+	has(key string) bool
+	// The file with the value of an existing key replaced by text
+	replace(key, text string) []byte
+	// The file with name = text added to the table parent, "" being the top
+	// level. False if there is no such table
+	add(parent, name, text string) ([]byte, bool)
+}
+
+// Byte range of a value in a file
+type span struct {
+	start int
+	end   int
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Change one value in the config file and leave the rest of it as it is. The
+// value and the changed file are checked before anything is written
+func SetConfigValue(configPath, key, text string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New(
+				"Config file " + configPath + " not found. Create it with pomodoro config init",
+			)
+		}
+		return err
+	}
+	value, err := parseValue(configPath, key, text)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(key, "_mp3") {
+		cfg := Config{path: configPath}
+		if !fileExists(cfg.soundPath(text)) {
+			return errors.New("Sound file " + text + " not found")
+		}
+	}
+	edited, err := setText(configPath, data, key, value)
+	if err != nil {
+		return err
+	}
+	if problems := newProblems(configPath, data, edited); len(problems) > 0 {
+		return problems[0]
+	}
+	return ioutil.WriteFile(configPath, edited, 0644)
+}
+
+// Convert text from the command line to the value of key the way environment
+// variables are read, and check it
+func parseValue(configPath, key, text string) (interface{}, error) {
+	field := key
+	switch {
+	case strings.HasPrefix(key, "profiles."):
+		rest := strings.TrimPrefix(key, "profiles.")
+		field = rest[strings.LastIndex(rest, ".")+1:]
+	case strings.HasPrefix(key, "key_bindings."):
+		field = "key_bindings"
+	}
+	var value interface{}
+	switch kind := fieldKinds[field]; {
+	case field == "key_bindings" && key != field:
+		names := []interface{}{}
+		for _, name := range strings.Split(text, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+		value = names
+	case kind == KIND_KEY_BINDINGS:
+		return nil, errors.New("Set key bindings one action at a time, e.g. key_bindings.start")
+	case kind == KIND_PROFILES:
+		return nil, errors.New("Set profile values one at a time, e.g. profiles.<name>.work_time")
+	case kind == KIND_DURATION:
+		value = text
+		if d, err := ParseDuration(text); err == nil {
+			value = d.String()
+		}
+	case kind == KIND_OPTIONAL_DURATION:
+		value = text
+		if d, err := ParseOptionalDuration(text); err == nil {
+			value = d.String()
+		}
+	default:
+		value = envValue(kind, text)
+	}
+	// Check it in place so unknown names are reported like in the file
+	values := map[string]interface{}{}
+	table := values
+	parts := strings.SplitN(key, ".", 2)
+	if parts[0] == "profiles" && len(parts) == 2 {
+		rest := parts[1]
+		split := strings.LastIndex(rest, ".")
+		parts = []string{"profiles", rest}
+		if split >= 0 {
+			parts = []string{"profiles", rest[:split], rest[split+1:]}
+		}
+		// Keys are split on dots, so the name would be read as nested tables
+		if strings.Contains(parts[1], ".") {
+			return nil, errors.New("Profile names can't contain \".\", got " + strconv.Quote(parts[1]))
+		}
+	}
+	for _, part := range parts[:len(parts)-1] {
+		inner := map[string]interface{}{}
+		table[part] = inner
+		table = inner
+	}
+	table[parts[len(parts)-1]] = value
+	v := validator{file: configPath}
+	v.fields(values, "", false)
+	if len(v.errs) > 0 {
+		return nil, v.errs[0]
+	}
+	return value, nil
+}
+
+// Errors in after that weren't in before. Positions are left out of the
+// comparison since the edit moves things around
+func newProblems(configPath string, before, after []byte) []error {
+	known := map[string]bool{}
+	for _, err := range configProblems(configPath, before) {
+		known[withoutPosition(err)] = true
+	}
+	problems := []error{}
+	for _, err := range configProblems(configPath, after) {
+		if !known[withoutPosition(err)] {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
+
+func configProblems(configPath string, data []byte) []error {
+	cfg := defaultConfig(configPath)
+	errs := cfg.decode(configPath, data)
+	if _, err := cfg.KeyBindings.Parse(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func withoutPosition(err error) string {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		plain := *verr
+		plain.Line, plain.Column = 0, 0
+		return plain.Error()
+	}
+	return err.Error()
+}
+
+// The file with key set to value. Missing tables are added as needed
+func setText(configPath string, data []byte, key string, value interface{}) ([]byte, error) {
+	var editor configEditor
+	var err error
+	switch configFormat(configPath) {
+	case FORMAT_TOML:
+		editor = newTomlEditor(data)
+	case FORMAT_YAML:
+		editor, err = newYamlEditor(data)
+	default:
+		editor, err = newJSONEditor(data)
+	}
+	if err != nil {
+		return nil, &ValidationError{File: configPath, Message: err.Error()}
+	}
+	if editor.has(key) {
+		return editor.replace(key, encodeValue(value)), nil
+	}
+	parent, name := splitKey(key)
+	for {
+		if edited, ok := editor.add(parent, name, encodeValue(value)); ok {
+			return edited, nil
+		}
+		if parent == "" || editor.has(parent) {
+			return nil, errors.New("Can't set " + key + ", " + parent + " is not a table")
+		}
+		value = map[string]interface{}{name: value}
+		parent, name = splitKey(parent)
+	}
+}
+
+// Values are written as JSON, which TOML and YAML read the same way for the
+// strings, numbers, bools and lists in a config
+func encodeValue(value interface{}) string {
+	buffer := bytes.Buffer{}
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSpace(buffer.String())
+}
+
+// e.g. "key_bindings", "start" for "key_bindings.start"
+func splitKey(key string) (string, string) {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		return "", key
+	}
+	return key[:i], key[i+1:]
+}
+
+func splice(data []byte, start, end int, text string) []byte {
+	edited := append([]byte{}, data[:start]...)
+	edited = append(edited, text...)
+	return append(edited, data[end:]...)
+}
+
+// Offset of the end of the line offset is on
+func lineEnd(data []byte, offset int) int {
+	if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(data)
+}
+
+// Whitespace at the start of the line offset is on
+func lineIndent(data []byte, offset int) string {
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := start
+	for end < len(data) && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// Offset just past the string that starts with a quote at start
+func skipString(data []byte, start int) int {
+	quote := data[start]
+	for i := start + 1; i < len(data); i++ {
+		switch {
+		case data[i] == '\\' && quote == '"':
+			i++
+		case data[i] == quote:
+			// '' is an escaped quote in YAML
+			if quote == '\'' && i+1 < len(data) && data[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// Offset just past the bracket that closes the one at start
+func skipBrackets(data []byte, start int) int {
+	depth := 0
+	for i := start; i < len(data); i++ {
+		switch data[i] {
+		case '"', '\'':
+			i = skipString(data, i) - 1
+		case '#':
+			i = lineEnd(data, i) - 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(data)
+}
+
+type jsonEditor struct {
+	data   []byte
+	values map[string]span
+	tables map[string]jsonObject
+}
+
+type jsonObject struct {
+	open int
+	// End of the last value, -1 if the object is empty
+	last   int
+	indent string
+	inline bool
+}
+
+func newJSONEditor(data []byte) (*jsonEditor, error) {
+	editor := &jsonEditor{data, map[string]span{}, map[string]jsonObject{}}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var walk func(prefix string, record bool) error
+	walk = func(prefix string, record bool) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'):
+			object := jsonObject{open: int(decoder.InputOffset()) - 1, last: -1}
+			for decoder.More() {
+				token, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				key := joinKey(prefix, token.(string))
+				keyEnd := int(decoder.InputOffset())
+				start := keyEnd
+				for start < len(data) && strings.ContainsRune(" \t\r\n:", rune(data[start])) {
+					start++
+				}
+				err = walk(key, record)
+				if err != nil {
+					return err
+				}
+				object.last = int(decoder.InputOffset())
+				object.indent = lineIndent(data, keyEnd)
+				object.inline = !bytes.Contains(data[object.open:keyEnd], []byte("\n"))
+				if record {
+					editor.values[key] = span{start, object.last}
+				}
+			}
+			_, err = decoder.Token()
+			if record {
+				editor.tables[prefix] = object
+			}
+		case json.Delim('['):
+			for decoder.More() {
+				err = walk(prefix, false)
+				if err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+		return err
+	}
+	if err := walk("", true); err != nil {
+		return nil, err
+	}
+	if _, ok := editor.tables[""]; !ok {
+		return nil, errors.New("expected a table of values")
+	}
+	return editor, nil
+}
+
+func (self *jsonEditor) has(key string) bool {
+	_, ok := self.values[key]
+	return ok
+}
+
+func (self *jsonEditor) replace(key, text string) []byte {
+	value := self.values[key]
+	return splice(self.data, value.start, value.end, text)
+}
+
+func (self *jsonEditor) add(parent, name, text string) ([]byte, bool) {
+	object, ok := self.tables[parent]
+	if !ok {
+		return nil, false
+	}
+	member := encodeValue(name) + ": " + text
+	switch {
+	case object.last < 0:
+		return splice(self.data, object.open+1, object.open+1, member), true
+	case object.inline:
+		return splice(self.data, object.last, object.last, ", "+member), true
+	default:
+		return splice(self.data, object.last, object.last, ",\n"+object.indent+member), true
+	}
+}
+
+type yamlEditor struct {
+	data   []byte
+	lines  []int
+	keys   map[string]*yaml.Node
+	values map[string]*yaml.Node
+	tables map[string]*yaml.Node
+}
+
+func newYamlEditor(data []byte) (*yamlEditor, error) {
+	editor := &yamlEditor{
+		data:   data,
+		lines:  []int{0},
+		keys:   map[string]*yaml.Node{},
+		values: map[string]*yaml.Node{},
+		tables: map[string]*yaml.Node{},
+	}
+	for i, b := range data {
+		if b == '\n' {
+			editor.lines = append(editor.lines, i+1)
+		}
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, errors.New("expected a table of values")
+		}
+		editor.walk(root, "")
+	}
+	return editor, nil
+}
+
+func (self *yamlEditor) walk(node *yaml.Node, prefix string) {
+	self.tables[prefix] = node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := joinKey(prefix, node.Content[i].Value)
+		self.keys[key] = node.Content[i]
+		self.values[key] = node.Content[i+1]
+		if node.Content[i+1].Kind == yaml.MappingNode {
+			self.walk(node.Content[i+1], key)
+		}
+	}
+}
+
+// Byte offset of where a node starts
+func (self *yamlEditor) offset(node *yaml.Node) int {
+	if node.Line < 1 || node.Line > len(self.lines) {
+		return len(self.data)
+	}
+	start := self.lines[node.Line-1]
+	line := string(self.data[start:lineEnd(self.data, start)])
+	runes := []rune(line)
+	if node.Column-1 > len(runes) {
+		return start + len(line)
+	}
+	return start + len(string(runes[:node.Column-1]))
+}
+
+// Byte offset just past a value, -1 if it can't be told
+func (self *yamlEditor) end(node *yaml.Node) int {
+	start := self.offset(node)
+	switch {
+	case node.Kind == yaml.ScalarNode:
+		switch node.Style {
+		case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+			return skipString(self.data, start)
+		case yaml.LiteralStyle, yaml.FoldedStyle:
+			return -1
+		}
+		text := string(self.data[start:lineEnd(self.data, start)])
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		return start + len(strings.TrimRight(text, " \t\r"))
+	case node.Style&yaml.FlowStyle != 0:
+		return skipBrackets(self.data, start)
+	case (node.Kind == yaml.SequenceNode || node.Kind == yaml.MappingNode) && len(node.Content) > 0:
+		return self.end(node.Content[len(node.Content)-1])
+	}
+	return -1
+}
+
+func (self *yamlEditor) has(key string) bool {
+	_, ok := self.values[key]
+	return ok
+}
+
+// Everything after the colon is replaced so block lists become flow lists
+func (self *yamlEditor) replace(key, text string) []byte {
+	keyNode, value := self.keys[key], self.values[key]
+	keyEnd := self.offset(keyNode) + len(keyNode.Value)
+	if keyNode.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		keyEnd = skipString(self.data, self.offset(keyNode))
+	}
+	start := keyEnd + bytes.IndexByte(self.data[keyEnd:], ':') + 1
+	end := self.end(value)
+	if value.Tag == "!!null" && value.Value == "" || end < start {
+		// Nothing after the colon
+		end = start
+	}
+	return splice(self.data, start, end, " "+text)
+}
+
+func (self *yamlEditor) add(parent, name, text string) ([]byte, bool) {
+	key := name
+	if !bareKey.MatchString(name) {
+		key = strconv.Quote(name)
+	}
+	table, ok := self.tables[parent]
+	if !ok {
+		if parent != "" {
+			return nil, false
+		}
+		// An empty file
+		data := self.data
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(append([]byte{}, data...), '\n')
+		}
+		return append(append([]byte{}, data...), yamlMember(key, text, "")+"\n"...), true
+	}
+	if len(table.Content) == 0 {
+		if table.Style&yaml.FlowStyle == 0 {
+			return nil, false
+		}
+		open := self.offset(table)
+		return splice(self.data, open+1, open+1, key+": "+text), true
+	}
+	last := self.end(table.Content[len(table.Content)-1])
+	if last < 0 {
+		return nil, false
+	}
+	if table.Style&yaml.FlowStyle != 0 {
+		return splice(self.data, last, last, ", "+key+": "+text), true
+	}
+	indent := strings.Repeat(" ", table.Content[0].Column-1)
+	at := lineEnd(self.data, last)
+	return splice(self.data, at, at, "\n"+indent+yamlMember(key, text, indent)), true
+}
+
+// key: text for a block table. A table in text, like a new profile, is written
+// as a block under the key instead of on its line
+func yamlMember(key, text, indent string) string {
+	var document yaml.Node
+	if !strings.HasPrefix(text, "{") || yaml.Unmarshal([]byte(text), &document) != nil {
+		return key + ": " + text
+	}
+	blockTables(&document)
+	buffer := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return key + ": " + text
+	}
+	lines := strings.Split(strings.TrimRight(buffer.String(), "\n"), "\n")
+	return key + ":\n" + indent + "  " + strings.Join(lines, "\n"+indent+"  ")
+}
+
+// Keys are only quoted where YAML needs it. Lists are left in flow style,
+// like the values replace writes
+func blockTables(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		node.Style &^= yaml.FlowStyle
+		for i := 0; i < len(node.Content); i += 2 {
+			node.Content[i].Style = 0
+		}
+	}
+	for _, child := range node.Content {
+		blockTables(child)
+	}
+}
+
+type tomlEditor struct {
+	data   []byte
+	values map[string]span
+	// Where to add a key to each table: the end of its last value's line or
+	// of its header. -1 to add at the start of the file
+	tables map[string]int
+}
+
+func newTomlEditor(data []byte) *tomlEditor {
+	editor := &tomlEditor{data, map[string]span{}, map[string]int{"": -1}}
+	table := ""
+	for offset := 0; offset < len(data); offset++ {
+		end := lineEnd(data, offset)
+		line := string(data[offset:end])
+		trimmed := strings.TrimSpace(line)
+		equals := strings.Index(line, "=")
+		switch {
+		case strings.HasPrefix(trimmed, "["):
+			header := strings.Trim(trimmed, "[] \t")
+			if end := strings.Index(header, "]"); end >= 0 {
+				header = header[:end]
+			}
+			table = strings.Join(splitTomlKey(header), ".")
+			editor.tables[table] = end
+		case trimmed != "" && !strings.HasPrefix(trimmed, "#") && equals >= 0:
+			key := joinKey(table, strings.Join(splitTomlKey(line[:equals]), "."))
+			start := offset + equals + 1
+			for start < end && (data[start] == ' ' || data[start] == '\t') {
+				start++
+			}
+			valueEnd := tomlValueEnd(data, start)
+			editor.values[key] = span{start, valueEnd}
+			end = lineEnd(data, valueEnd)
+			editor.tables[table] = end
+		}
+		offset = end
+	}
+	return editor
+}
+
+// Offset just past the value that starts at start
+func tomlValueEnd(data []byte, start int) int {
+	if start >= len(data) {
+		return start
+	}
+	rest := data[start:]
+	switch {
+	case bytes.HasPrefix(rest, []byte(`"""`)), bytes.HasPrefix(rest, []byte(`'''`)):
+		if i := bytes.Index(rest[3:], rest[:3]); i >= 0 {
+			return start + 3 + i + 3
+		}
+		return len(data)
+	case rest[0] == '"' || rest[0] == '\'':
+		if rest[0] == '\'' {
+			if i := bytes.IndexByte(rest[1:], '\''); i >= 0 {
+				return start + i + 2
+			}
+		}
+		return skipString(data, start)
+	case rest[0] == '[' || rest[0] == '{':
+		return skipBrackets(data, start)
+	}
+	end := lineEnd(data, start)
+	text := string(data[start:end])
+	if i := strings.Index(text, "#"); i >= 0 {
+		text = text[:i]
+	}
+	return start + len(strings.TrimRight(text, " \t\r"))
+}
+
+func (self *tomlEditor) has(key string) bool {
+	_, ok := self.values[key]
+	return ok
+}
+
+func (self *tomlEditor) replace(key, text string) []byte {
+	value := self.values[key]
+	return splice(self.data, value.start, value.end, text)
+}
+
+func (self *tomlEditor) add(parent, name, text string) ([]byte, bool) {
+	line := tomlKey(name) + " = " + text
+	at, ok := self.tables[parent]
+	switch {
+	case ok && at < 0:
+		return splice(self.data, 0, 0, line+"\n"), true
+	case ok:
+		return splice(self.data, at, at, "\n"+line), true
+	}
+	if _, isValue := self.values[parent]; isValue {
+		return nil, false
+	}
+	parts := strings.Split(parent, ".")
+	for i, part := range parts {
+		parts[i] = tomlKey(part)
+	}
+	data := append([]byte{}, self.data...)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	table := "\n[" + strings.Join(parts, ".") + "]\n" + line + "\n"
+	return append(data, table...), true
+}
+
+func tomlKey(name string) string {
+	if bareKey.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetText(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		data     string
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{
+			"config.json",
+			"{\n  \"work_time\": \"25m\", \"auto_start\": false\n}\n",
+			"work_time", "50m",
+			"{\n  \"work_time\": \"50m\", \"auto_start\": false\n}\n",
+		},
+		{
+			"config.json",
+			"{\n  \"work_time\": \"25m\"\n}\n",
+			"key_bindings.start", []interface{}{"g"},
+			"{\n  \"work_time\": \"25m\",\n  \"key_bindings\": {\"start\":[\"g\"]}\n}\n",
+		},
+		{
+			"config.json",
+			"{\"key_bindings\": {}}",
+			"key_bindings.start", []interface{}{"g"},
+			"{\"key_bindings\": {\"start\": [\"g\"]}}",
+		},
+		{
+			"config.toml",
+			"# Work\nwork_time = \"25m\" # comment\n\n[key_bindings]\nstart = [\n  \"s\",\n]\n",
+			"work_time", "50m",
+			"# Work\nwork_time = \"50m\" # comment\n\n[key_bindings]\nstart = [\n  \"s\",\n]\n",
+		},
+		{
+			"config.toml",
+			"work_time = \"25m\"\n\n[key_bindings]\nstart = [\n  \"s\",\n]\n",
+			"key_bindings.start", []interface{}{"g"},
+			"work_time = \"25m\"\n\n[key_bindings]\nstart = [\"g\"]\n",
+		},
+		{
+			"config.toml",
+			"work_time = \"25m\"\n\n[key_bindings]\nstart = [\"s\"]\n",
+			"auto_start", true,
+			"work_time = \"25m\"\nauto_start = true\n\n[key_bindings]\nstart = [\"s\"]\n",
+		},
+		{
+			"config.toml",
+			"work_time = \"25m\"\n",
+			"profiles.deep work.work_time", "50m",
+			"work_time = \"25m\"\n\n[profiles.\"deep work\"]\nwork_time = \"50m\"\n",
+		},
+		{
+			"config.yml",
+			"work_time: 25m # comment\nkey_bindings:\n  start:\n    - s\n    - Space\nauto_start: false\n",
+			"key_bindings.start", []interface{}{"g"},
+			"work_time: 25m # comment\nkey_bindings:\n  start: [\"g\"]\nauto_start: false\n",
+		},
+		{
+			"config.yml",
+			"work_time: \"25m\"\nkey_bindings:\n  start: [s]\n",
+			"key_bindings.pause", []interface{}{"x"},
+			"work_time: \"25m\"\nkey_bindings:\n  start: [s]\n  pause: [\"x\"]\n",
+		},
+		{
+			"config.yml",
+			"work_time: 25m\n",
+			"profiles.deep work.break_time", "10m",
+			"work_time: 25m\nprofiles:\n  deep work:\n    break_time: \"10m\"\n",
+		},
+		{
+			"config.yml",
+			"profiles:\n  classic:\n    work_time: 25m\n",
+			"profiles.deep work.key_bindings.start", []interface{}{"g"},
+			"profiles:\n  classic:\n    work_time: 25m\n  \"deep work\":\n    key_bindings:\n      start: [\"g\"]\n",
+		},
+	} {
+		got, err := setText(test.name, []byte(test.data), test.key, test.value)
+		if err != nil {
+			t.Error("Expected no error setting", test.key, "in", test.name, "Got:", err)
+			continue
+		}
+		if string(got) != test.expected {
+			t.Errorf("Expected setting %s in %s to give:\n%s\nGot:\n%s",
+				test.key, test.name, test.expected, got)
+		}
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, DEFAULT_SOUND_PATH), []byte{}, 0644)
+	path := filepath.Join(dir, "config.toml")
+	err := InitConfig(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := InitConfig(path, false); err == nil {
+		t.Error("Expected init to refuse to replace the file")
+	}
+	for key, value := range map[string]string{
+		"work_time":          "50",
+		"auto_start":         "yes",
+		"key_bindings.start": "g, Space",
+	} {
+		if err := SetConfigValue(path, key, value); err != nil {
+			t.Error("Expected to set", key, "Got:", err)
+		}
+	}
+	for key, message := range map[string]string{
+		"work_tme":                `did you mean "work_time"?`,
+		"break_time":              "must be at least 1s",
+		"key_bindings.pause":      `bound to both`,
+		"work_mp3":                "not found",
+		"key_bindings":            "one action at a time",
+		"profiles.v1.2.work_time": `can't contain "."`,
+	} {
+		err := SetConfigValue(path, key, "0")
+		switch key {
+		case "key_bindings.pause":
+			err = SetConfigValue(path, key, "g")
+		case "profiles.v1.2.work_time":
+			err = SetConfigValue(path, key, "50m")
+		}
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Error("Expected setting", key, "to fail with", message, "Got:", err)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# Work time, e.g.") {
+		t.Error("Expected the comments to be kept. Got:", string(data))
+	}
+	cfg, errs := ReadConfig(path, "")
+	if len(errs) > 0 {
+		t.Error("Expected no errors. Got:", errs)
+	}
+	if cfg.WorkTime != 50*60 || !cfg.AutoStart || cfg.BreakTime != DEFAULT_BREAK_TIME {
+		t.Error("Expected 50m with auto start. Got:", cfg.WorkTime, cfg.AutoStart, cfg.BreakTime)
+	}
+	value, _ := cfg.Get("key_bindings.start")
+	if value.Text() != "g,Space" {
+		t.Error("Expected g,Space. Got:", value.Text())
+	}
+}
+
+func TestReadConfigDoesNotCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg, _ := ReadConfig(path, "")
+	if fileExists(path) {
+		t.Error("Expected", path, "not to be created")
+	}
+	if cfg.WorkTime != DEFAULT_WORK_TIME {
+		t.Error("Expected the default work time. Got:", cfg.WorkTime)
+	}
+}
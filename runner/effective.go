@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Sources of values that weren't read from a file, a profile or the environment
+const (
+	SOURCE_DEFAULT = "default"
+	SOURCE_ARGS    = "command line"
+)
+
+// A value in use and where it came from
+type ConfigValue struct {
+	// e.g. "work_time" or "key_bindings.start"
+	Key string
+	// A string, bool, int64 or list of strings
+	Value  interface{}
+	Source string
+}
+
+// Text of the value like it is given to config set, e.g. 50m or s,Space
+func (self ConfigValue) Text() string {
+	switch value := self.Value.(type) {
+	case string:
+		return value
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, ConfigValue{Value: item}.Text())
+		}
+		return strings.Join(items, ",")
+	case int64:
+		return strconv.FormatInt(value, 10)
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
+
+func (self *Config) setSource(key, source string) {
+	if self.sources == nil {
+		self.sources = map[string]string{}
+	}
+	self.sources[key] = source
+}
+
+// Record the source of every value in valid, which is JSON in the config file
+// layout. Key bindings are recorded per action
+func (self *Config) setSources(valid []byte, source func(key string) string) {
+	var values map[string]json.RawMessage
+	if json.Unmarshal(valid, &values) != nil {
+		return
+	}
+	for key, value := range values {
+		if key != "key_bindings" {
+			self.setSource(key, source(key))
+			continue
+		}
+		var bindings map[string]json.RawMessage
+		if json.Unmarshal(value, &bindings) != nil {
+			continue
+		}
+		for action := range bindings {
+			field := joinKey(key, action)
+			self.setSource(field, source(field))
+		}
+	}
+}
+
+// Every value in use in the order of the config file. Key bindings are listed
+// per action and profiles are left out
+func (self *Config) Values() []ConfigValue {
+	fields, err := fieldMap(*self)
+	if err != nil {
+		return nil
+	}
+	values := []ConfigValue{}
+	add := func(key string, value interface{}) {
+		source, ok := self.sources[key]
+		if !ok {
+			source = SOURCE_DEFAULT
+		}
+		values = append(values, ConfigValue{key, value, source})
+	}
+	for _, key := range fieldKeys() {
+		data, ok := fields[key]
+		if !ok || key == "profiles" {
+			continue
+		}
+		value, err := plainValue(data)
+		if err != nil {
+			continue
+		}
+		if key != "key_bindings" {
+			add(key, value)
+			continue
+		}
+		bindings, _ := value.(map[string]interface{})
+		for _, action := range ACTIONS {
+			if names, ok := bindings[action]; ok {
+				add(joinKey(key, action), names)
+			}
+		}
+	}
+	return values
+}
+
+// The value in use for a key like "work_time" or "key_bindings.start"
+func (self *Config) Get(key string) (ConfigValue, error) {
+	values := self.Values()
+	keys := make([]string, 0, len(values))
+	for _, value := range values {
+		if value.Key == key {
+			return value, nil
+		}
+		keys = append(keys, value.Key)
+	}
+	errMsg := "Unknown config key \"" + key + "\""
+	if suggestion := closest(key, keys); suggestion != "" {
+		errMsg += ", did you mean \"" + suggestion + "\"?"
+	}
+	return ConfigValue{}, errors.New(errMsg)
+}
@@ -0,0 +1,39 @@
+package runner
+
+import "testing"
+
+func TestValueSources(t *testing.T) {
+	path := writeProfileConfig(t)
+	cfg, errs := NewConfig(path, "deep work")
+	if len(errs) > 0 {
+		t.Fatal("Expected no errors. Got:", errs)
+	}
+	cfg.readEnv([]string{"POMODORO_BREAK_CHAR=☕", "POMODORO_KEY_SKIP=n"})
+	cfg.ReadArgs("", "", "", 0, 3, false)
+	for key, expected := range map[string]string{
+		"work_time":         `profile "deep work"`,
+		"long_break_time":   SOURCE_DEFAULT,
+		"break_char":        "POMODORO_BREAK_CHAR",
+		"key_bindings.skip": "POMODORO_KEY_SKIP",
+		"key_bindings.quit": SOURCE_DEFAULT,
+		"total_pomodoros":   SOURCE_ARGS,
+	} {
+		value, err := cfg.Get(key)
+		if err != nil || value.Source != expected {
+			t.Error("Expected", key, "to come from", expected, "Got:", value.Source, err)
+		}
+	}
+	value, _ := cfg.Get("work_time")
+	if value.Text() != "50m" {
+		t.Error("Expected 50m. Got:", value.Text())
+	}
+	_, err := cfg.Get("work_tim")
+	if err == nil || err.Error() != `Unknown config key "work_tim", did you mean "work_time"?` {
+		t.Error("Expected a suggestion. Got:", err)
+	}
+	for _, value := range cfg.Values() {
+		if value.Key == "profiles" || value.Key == "key_bindings" {
+			t.Error("Expected profiles and key_bindings to be left out. Got:", value.Key)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ENV_PREFIX = "POMODORO_"
+	// Key bindings are set per action, e.g. POMODORO_KEY_START="s,Space"
+	ENV_KEY_PREFIX = ENV_PREFIX + "KEY_"
+	// Shown as the file in errors about environment variables
+	ENV_SOURCE = "environment"
+)
+
+// Name of the environment variable for a config field, e.g. POMODORO_WORK_TIME
+func envName(key string) string {
+	return ENV_PREFIX + strings.ToUpper(key)
+}
+
+// Environment variables for every config field except profiles, and the
+// fields they set
+func envFields() map[string]string {
+	fields := map[string]string{}
+	for key, kind := range fieldKinds {
+		switch kind {
+		case KIND_PROFILES:
+		case KIND_KEY_BINDINGS:
+			for _, action := range ACTIONS {
+				fields[ENV_KEY_PREFIX+strings.ToUpper(action)] = joinKey(key, action)
+			}
+		default:
+			fields[envName(key)] = key
+		}
+	}
+	return fields
+}
+
+// Read POMODORO_* variables from environ, given as "NAME=value" like
+// os.Environ. Returns the valid values as JSON in the config file layout
+func readEnv(environ []string) ([]byte, []error) {
+	fields := envFields()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	v := validator{file: ENV_SOURCE, names: map[string]string{}}
+	values := map[string]interface{}{}
+	bindings := map[string]interface{}{}
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, ENV_PREFIX) || name == CONFIG_ENV || value == "" {
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			v.suggest(name, nil, "unknown variable", closest(name, names))
+			continue
+		}
+		v.names[field] = name
+		if strings.HasPrefix(field, "key_bindings.") {
+			action := strings.TrimPrefix(field, "key_bindings.")
+			keys := []interface{}{}
+			for _, key := range strings.Split(value, ",") {
+				keys = append(keys, strings.TrimSpace(key))
+			}
+			bindings[action] = keys
+			continue
+		}
+		values[field] = envValue(fieldKinds[field], value)
+	}
+	if len(bindings) > 0 {
+		values["key_bindings"] = bindings
+	}
+	v.fields(values, "", false)
+	valid, err := json.Marshal(values)
+	if err != nil {
+		return nil, append(v.errs, err)
+	}
+	return valid, v.errs
+}
+
+// Convert the text of a variable to the type the validator expects. Text
+// that can't be converted is left for the validator to report
+func envValue(kind int, value string) interface{} {
+	switch kind {
+	case KIND_BOOL:
+		switch strings.ToLower(value) {
+		case "1", "true", "yes", "on":
+			return true
+		case "0", "false", "no", "off":
+			return false
+		}
+	case KIND_LIST:
+		items := []interface{}{}
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+		return items
+	case KIND_COUNT, KIND_OPTIONAL_COUNT, KIND_PERCENT:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
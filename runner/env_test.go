@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"testing"
+)
+
+func TestReadEnv(t *testing.T) {
+	cfg := Config{KeyBindings: DefaultKeyBindings()}
+	errs := cfg.readEnv([]string{
+		"HOME=/root",
+		"POMODORO_WORK_TIME=50m",
+		"POMODORO_AUTO_START=yes",
+		"POMODORO_TOTAL_POMODOROS=4",
+		"POMODORO_BREAK_CHAR=☕",
+		"POMODORO_KEY_START=s, Space",
+		"POMODORO_CONFIG=config.json",
+		"POMODORO_EMPTY_CHAR=",
+	})
+	if len(errs) > 0 {
+		t.Error("Expected no errors. Got:", errs)
+	}
+	if cfg.WorkTime != 50*60 || !cfg.AutoStart || cfg.TotalPomodoros != 4 || cfg.BreakChar != "☕" {
+		t.Error("Expected the values from the environment. Got:",
+			int(cfg.WorkTime), cfg.AutoStart, cfg.TotalPomodoros, cfg.BreakChar)
+	}
+	start := cfg.KeyBindings[ACTION_START]
+	if len(start) != 2 || start[1] != "Space" || cfg.KeyBindings[ACTION_PAUSE][0] != "p" {
+		t.Error("Expected start to be bound to s and Space. Got:", cfg.KeyBindings)
+	}
+}
+
+func TestReadEnvErrors(t *testing.T) {
+	cfg := Config{WorkTime: DEFAULT_WORK_TIME, TotalPomodoros: DEFAULT_TOTAL_POMODOROS}
+	errs := cfg.readEnv([]string{
+		"POMODORO_WORK_TIME=0",
+		"POMODORO_TOTAL_POMODOROS=many",
+		"POMODORO_WROK_TIME=50m",
+	})
+	expected := []string{
+		"environment: POMODORO_WROK_TIME: unknown variable, did you mean \"POMODORO_WORK_TIME\"?",
+		"environment: POMODORO_TOTAL_POMODOROS: expected a whole number, got \"many\"",
+		"environment: POMODORO_WORK_TIME: must be at least 1s, got \"0\"",
+	}
+	if len(errs) != len(expected) {
+		t.Fatal("Expected", len(expected), "errors. Got:", errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Error("Expected:", expected[i], "Got:", err.Error())
+		}
+	}
+	if cfg.WorkTime != DEFAULT_WORK_TIME || cfg.TotalPomodoros != DEFAULT_TOTAL_POMODOROS {
+		t.Error("Expected invalid values to be ignored. Got:", int(cfg.WorkTime), cfg.TotalPomodoros)
+	}
+}
+
+// defaults < file < profile < environment < flags
+func TestEnvPrecedence(t *testing.T) {
+	path := writeProfileConfig(t)
+	t.Setenv("POMODORO_BREAK_TIME", "7m")
+	t.Setenv("POMODORO_DEFAULT_PROFILE", "deep work")
+	cfg, errs := NewConfig(path, "")
+	if len(errs) > 0 {
+		t.Error("Expected no errors. Got:", errs)
+	}
+	if cfg.Profile() != "deep work" || cfg.WorkTime != 50*60 || cfg.BreakTime != 7*60 {
+		t.Error("Expected 50m from the profile and 7m from the environment. Got:",
+			cfg.Profile(), cfg.WorkTime, cfg.BreakTime)
+	}
+	cfg.ReadArgs("", "2m", "", 0, 0, false)
+	if cfg.BreakTime != 2*60 {
+		t.Error("Expected flags to override the environment. Got:", cfg.BreakTime)
+	}
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats, picked by the extension of the file
+const (
+	FORMAT_JSON = "json"
+	FORMAT_TOML = "toml"
+	FORMAT_YAML = "yaml"
+)
+
+// Extensions looked for when searching for a config file
+var CONFIG_EXTENSIONS = []string{".json", ".toml", ".yaml", ".yml"}
+
+// Written above each field in TOML and YAML files
+var fieldComments = map[string]string{
+	"work_mp3":               "Sound played when a work timer finishes. Relative to this file",
+	"break_mp3":              "Sound played when a break finishes. Relative to this file",
+	"volume":                 "Volume of the sounds in percent, from 0 to 100",
+	"work_time":              "Work time, e.g. \"25m\", \"90s\" or \"1h15m\". Plain numbers are minutes",
+	"break_time":             "Short break time",
+	"long_break_time":        "Long break time",
+	"long_break_interval":    "Number of pomodoros before a long break",
+	"auto_start":             "Start the next timer when one finishes",
+	"total_pomodoros":        "Total number of pomodoros in a session",
+	"count_skipped_work":     "Count skipped pomodoros towards the long break interval",
+	"pomodoro_char":          "Marker for a finished pomodoro",
+	"break_char":             "Marker for a break left",
+	"empty_char":             "Marker for a pomodoro left",
+	"history_file":           "Where finished phases are recorded. Relative to this file. Empty for the default",
+	"daily_goal":             "Pomodoros to finish each day. 0 for no goal",
+	"weekly_goal":            "Pomodoros to finish each week, starting on Monday. 0 for no goal",
+	"daily_focus_goal":       "Time to spend working each day, e.g. \"4h\". \"0s\" for no goal",
+	"weekly_focus_goal":      "Time to spend working each week. \"0s\" for no goal",
+	"goal_command":           "Command run when a goal is reached, with the goal in $POMODORO_GOAL",
+	"short_break_activities": "Activities suggested for short breaks",
+	"long_break_activities":  "Activities suggested for long breaks",
+	"activities_file":        "File with more activities, one per line under [short] or [long]. Relative to this file",
+	"idle_timeout":           "Pause work after this long without a key press, e.g. \"5m\". \"0s\" to never pause",
+	"idle_command":           "Command printing the idle time in milliseconds, e.g. \"xprintidle\". Used instead of key presses",
+	"working_hours":          "Times of day to work in, e.g. \"09:00-17:30\". The session waits for the start and ends after the end",
+	"lunch":                  "Times of day no work is started in, e.g. \"12:30-13:15\"",
+	"activity_order":         "How activities are picked: \"random\" or \"round_robin\". Each one is used once before any repeats",
+	"key_bindings":           "Keys for each action, e.g. \"s\", \"Space\", \"Ctrl+S\" or \"MouseLeft\"",
+	"default_profile":        "Profile used when --profile isn't given",
+	"profiles":               "Named profiles. Each one overrides any of the values above",
+}
+
+// JSON is used for anything that isn't .toml, .yaml or .yml
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return FORMAT_TOML
+	case ".yaml", ".yml":
+		return FORMAT_YAML
+	default:
+		return FORMAT_JSON
+	}
+}
+
+// Encode a config as written to the file
+func encodeConfig(path string, cfg *Config) ([]byte, error) {
+	if configFormat(path) == FORMAT_JSON {
+		return json.MarshalIndent(cfg, "", "  ")
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	// Fields are written in the order of the struct. TOML needs plain values
+	// before tables
+	keys := []string{}
+	tables := []string{}
+	for _, key := range fieldKeys() {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if bytes.HasPrefix(value, []byte("{")) {
+			tables = append(tables, key)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	buf := bytes.Buffer{}
+	for i, key := range append(keys, tables...) {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if comment, ok := fieldComments[key]; ok {
+			buf.WriteString("# " + comment + "\n")
+		}
+		if configFormat(path) == FORMAT_YAML && !bytes.HasPrefix(fields[key], []byte("{")) {
+			// JSON values are valid YAML, and unlike the YAML encoder this
+			// doesn't escape emoji
+			buf.WriteString(key + ": " + string(fields[key]) + "\n")
+			continue
+		}
+		value, err := plainValue(fields[key])
+		if err != nil {
+			return nil, err
+		}
+		field := map[string]interface{}{key: value}
+		if configFormat(path) == FORMAT_TOML {
+			encoder := toml.NewEncoder(&buf)
+			encoder.Indent = ""
+			err = encoder.Encode(field)
+		} else {
+			encoder := yaml.NewEncoder(&buf)
+			encoder.SetIndent(2)
+			err = encoder.Encode(field)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode a JSON value keeping whole numbers as integers
+func plainValue(data json.RawMessage) (value interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	return integers(value), nil
+}
+
+func integers(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case map[string]interface{}:
+		for key, v := range value {
+			value[key] = integers(v)
+		}
+	case []interface{}:
+		for i, v := range value {
+			value[i] = integers(v)
+		}
+	}
+	return value
+}
+
+// Keys of the config fields in the order they are declared
+func fieldKeys() []string {
+	keys := []string{}
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		tag := configType.Field(i).Tag.Get("json")
+		key := strings.Split(tag, ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	JSON_CONFIG = `{"work_time": 50, "auto_start": true, "break_char": "☕",
+  "key_bindings": {"start": ["s", "Space"]},
+  "profiles": {"short": {"work_time": 15}}}`
+	TOML_CONFIG = `# Deep work
+work_time = 50
+auto_start = true
+break_char = "☕"
+
+[key_bindings]
+start = ["s", "Space"]
+
+[profiles.short]
+work_time = 15
+`
+	YAML_CONFIG = `# Deep work
+work_time: 50
+auto_start: true
+break_char: ☕
+key_bindings:
+  start: [s, Space]
+profiles:
+  short:
+    work_time: 15
+`
+)
+
+func TestConfigFormat(t *testing.T) {
+	for path, format := range map[string]string{
+		"config.json": FORMAT_JSON,
+		"config.TOML": FORMAT_TOML,
+		"config.yaml": FORMAT_YAML,
+		"config.yml":  FORMAT_YAML,
+		"config":      FORMAT_JSON,
+	} {
+		if got := configFormat(path); got != format {
+			t.Error("Expected", path, "to be", format, "Got:", got)
+		}
+	}
+}
+
+// The same config written in each format is read the same way
+func TestReadFormats(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, DEFAULT_SOUND_PATH), []byte{}, 0644)
+	configs := map[string]Config{}
+	for name, data := range map[string]string{
+		"config.json": JSON_CONFIG,
+		"config.toml": TOML_CONFIG,
+		"config.yml":  YAML_CONFIG,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(data), 0644)
+		cfg, errs := NewConfig(path, "short")
+		if len(errs) > 0 {
+			t.Error("Expected no errors reading", name, "Got:", errs)
+		}
+		if cfg.WorkTime != 15*60 || !cfg.AutoStart || cfg.BreakChar != "☕" {
+			t.Error("Expected the values from", name, "Got:", cfg.WorkTime, cfg.AutoStart, cfg.BreakChar)
+		}
+		cfg.path = ""
+		cfg.base = nil
+		cfg.sources = nil
+		configs[name] = cfg
+	}
+	if !reflect.DeepEqual(configs["config.json"], configs["config.toml"]) ||
+		!reflect.DeepEqual(configs["config.json"], configs["config.yml"]) {
+		t.Error("Expected every format to give the same config. Got:", configs)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"config.json", "config.toml", "config.yaml"} {
+		path := filepath.Join(dir, name)
+		created, _ := NewConfig(path, "")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal("Expected a default config to be created. Got:", err)
+		}
+		if name != "config.json" && !strings.Contains(string(data), "# Short break time") {
+			t.Error("Expected comments in", name, "Got:", string(data))
+		}
+		read, _ := NewConfig(path, "")
+		if !reflect.DeepEqual(created.KeyBindings, read.KeyBindings) ||
+			created.WorkTime != read.WorkTime ||
+			created.WorkChar != read.WorkChar {
+			t.Error("Expected", name, "to read back the same values")
+		}
+	}
+}
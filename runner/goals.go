@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"pomodoro/history"
+	"pomodoro/view"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Pomodoros and work time recorded today and this week
+type goalProgress struct {
+	day   time.Time
+	today history.Summary
+	week  history.Summary
+	// Goals already announced, keyed by their config field
+	reached map[string]bool
+}
+
+// Goals set in the config with the progress made towards them
+func (self *Config) Goals(today, week history.Summary) []view.Goal {
+	goals := []view.Goal{}
+	if self.DailyGoal > 0 {
+		goals = append(goals, view.Goal{Period: view.PERIOD_DAY, Done: today.Pomodoros, Target: self.DailyGoal})
+	}
+	if self.DailyFocusGoal > 0 {
+		goals = append(goals, view.Goal{
+			Period: view.PERIOD_DAY, Done: today.WorkTime, Target: int(self.DailyFocusGoal), Time: true,
+		})
+	}
+	if self.WeeklyGoal > 0 {
+		goals = append(goals, view.Goal{Period: view.PERIOD_WEEK, Done: week.Pomodoros, Target: self.WeeklyGoal})
+	}
+	if self.WeeklyFocusGoal > 0 {
+		goals = append(goals, view.Goal{
+			Period: view.PERIOD_WEEK, Done: week.WorkTime, Target: int(self.WeeklyFocusGoal), Time: true,
+		})
+	}
+	return goals
+}
+
+// Config field a goal comes from, e.g. "daily_goal"
+func goalKey(goal view.Goal) string {
+	key := "daily_"
+	if goal.Period == view.PERIOD_WEEK {
+		key = "weekly_"
+	}
+	if goal.Time {
+		key += "focus_"
+	}
+	return key + "goal"
+}
+
+// e.g. "Daily goal reached: 10 pomodoros"
+func goalMessage(goal view.Goal) string {
+	text := "Daily goal reached: "
+	if goal.Period == view.PERIOD_WEEK {
+		text = "Weekly goal reached: "
+	}
+	if goal.Time {
+		return text + Duration(goal.Target).String() + " of work"
+	}
+	if goal.Target == 1 {
+		return text + "1 pomodoro"
+	}
+	return text + strconv.Itoa(goal.Target) + " pomodoros"
+}
+
+// Read the totals for today and this week from the history. Goals that are
+// already reached aren't announced again. Must be called with the lock held
+func (self *session) loadProgress() {
+	now := time.Now()
+	progress := goalProgress{day: history.StartOfDay(now), reached: map[string]bool{}}
+	if self.history != nil {
+		// A bad line still leaves the entries before it
+		entries, err := self.history.Read(history.StartOfWeek(now), time.Time{})
+		if err != nil {
+			self.notify("Failed to read history: " + err.Error())
+		}
+		progress.today = history.Summarize(history.Between(entries, progress.day, time.Time{}))
+		progress.week = history.Summarize(entries)
+	}
+	self.progress = progress
+	for _, goal := range self.goals() {
+		if goal.Reached() {
+			self.progress.reached[goalKey(goal)] = true
+		}
+	}
+}
+
+// Count a phase that was just recorded. Must be called with the lock held
+func (self *session) addProgress(entry history.Entry) {
+	if entry.Start.Before(history.StartOfWeek(self.progress.day)) {
+		return
+	}
+	self.progress.week.Add(entry)
+	if !entry.Start.Before(self.progress.day) {
+		self.progress.today.Add(entry)
+	}
+}
+
+// Progress including the work phase in progress. Must be called with the
+// lock held
+func (self *session) goals() []view.Goal {
+	today, week := self.progress.today, self.progress.week
+	if self.current.phase == history.PHASE_WORK {
+		today.WorkTime += self.current.elapsed
+		week.WorkTime += self.current.elapsed
+	}
+	return self.cfg.Goals(today, week)
+}
+
+// Announce each goal once when it is reached and start over at midnight. Must
+// be called with the lock held
+func (self *session) checkGoals() {
+	if !history.StartOfDay(time.Now()).Equal(self.progress.day) {
+		self.loadProgress()
+	}
+	for _, goal := range self.goals() {
+		key := goalKey(goal)
+		if !goal.Reached() || self.progress.reached[key] {
+			continue
+		}
+		self.progress.reached[key] = true
+		message := goalMessage(goal)
+		self.notify(message)
+		if self.cfg.GoalCommand != "" {
+			go self.runGoalCommand(self.cfg.GoalCommand, key, message)
+		}
+	}
+}
+
+// Run command with the goal in $POMODORO_GOAL, e.g. "daily_goal", and the
+// message in $POMODORO_GOAL_MESSAGE
+func (self *session) runGoalCommand(command, key, message string) {
+	cmd := shellCommand(command)
+	cmd.Env = append(os.Environ(), "POMODORO_GOAL="+key, "POMODORO_GOAL_MESSAGE="+message)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		self.mu.Lock()
+		self.notify("goal_command failed: " + firstLine(err.Error()+"\n"+string(output)))
+		self.mu.Unlock()
+	}
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
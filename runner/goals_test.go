@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"pomodoro/history"
+	"pomodoro/view"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func addPomodoro(t *testing.T, store *history.Store) {
+	err := store.Append(history.Entry{
+		Phase: history.PHASE_WORK, Start: time.Now(), End: time.Now(),
+		Planned: 3, Actual: 3, Completed: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.DailyGoal = 2
+	s.cfg.WeeklyFocusGoal = 10
+	addPomodoro(t, s.history)
+	s.loadProgress()
+	goals := s.goals()
+	if len(goals) != 2 || goals[0].Done != 1 || goals[1].Done != 3 {
+		t.Fatal("Expected 1 pomodoro today and 3s this week. Got:", goals)
+	}
+	s.act(ACTION_START)
+	s.tickAndRecord(2)
+	if text := view.GoalText(s.goals(), "X"); text != "Today: 1/2 X  Week: 5s/10s" {
+		t.Error("Expected the work in progress to count. Got:", text)
+	}
+	if s.currentMessage() != "" {
+		t.Error("Expected no goal to be reached yet. Got:", s.currentMessage())
+	}
+	s.tickAndRecord(2)
+	if s.currentMessage() != "Daily goal reached: 2 pomodoros" {
+		t.Error("Expected the daily goal to be reached. Got:", s.currentMessage())
+	}
+	if model := s.model(); view.GoalText(model.Goals, "X") != "Today: 2/2 X  Week: 6s/10s" {
+		t.Error("Expected the goals in the model. Got:", model.Goals)
+	}
+}
+
+func TestReachedGoalsAreNotAnnouncedAgain(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.DailyGoal = 1
+	addPomodoro(t, s.history)
+	s.loadProgress()
+	s.checkGoals()
+	if s.currentMessage() != "" {
+		t.Error("Expected no message for a goal reached earlier. Got:", s.currentMessage())
+	}
+}
+
+func TestGoalCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	s := newRecordSession(t)
+	output := filepath.Join(t.TempDir(), "goal")
+	s.cfg.WeeklyGoal = 1
+	s.cfg.GoalCommand = "echo $POMODORO_GOAL: $POMODORO_GOAL_MESSAGE > " + output
+	s.loadProgress()
+	s.act(ACTION_START)
+	s.tickAndRecord(4)
+	var data []byte
+	for i := 0; i < 50 && len(data) == 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+		data, _ = os.ReadFile(output)
+	}
+	if strings.TrimSpace(string(data)) != "weekly_goal: Weekly goal reached: 1 pomodoro" {
+		t.Error("Expected the command to run with the goal. Got:", string(data))
+	}
+}
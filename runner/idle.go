@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"errors"
+	"pomodoro/timer"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How often the idle time is checked
+const IDLE_CHECK_INTERVAL = 5 * time.Second
+
+// Idle time found during a work phase, waiting for the user to say whether it
+// was work
+type idlePrompt struct {
+	seconds int
+	// Start of the phase it was found in
+	phaseStart time.Time
+}
+
+// Pause work when nobody has been at the computer for idle_timeout. The idle
+// time comes from idle_command, or the last input when there is none.
+// Returns once the session is quit
+func (self *session) watchIdle() {
+	ticker := time.NewTicker(IDLE_CHECK_INTERVAL)
+	defer ticker.Stop()
+	failed := false
+	for {
+		select {
+		case <-self.done:
+			return
+		case <-ticker.C:
+		}
+		self.mu.Lock()
+		command := self.cfg.IdleCommand
+		waiting := self.cfg.IdleTimeout == 0 || self.tmr.TimerState() != timer.WORK
+		self.mu.Unlock()
+		if waiting {
+			continue
+		}
+		idle := time.Duration(0)
+		if command != "" {
+			var err error
+			idle, err = commandIdleTime(command)
+			if err != nil {
+				if !failed {
+					self.mu.Lock()
+					self.notify("idle_command failed: " + firstLine(err.Error()))
+					self.mu.Unlock()
+				}
+				failed = true
+				continue
+			}
+			failed = false
+		}
+		self.mu.Lock()
+		if command == "" {
+			idle = time.Since(self.activeAt)
+		}
+		self.checkIdle(idle)
+		self.render()
+		self.mu.Unlock()
+	}
+}
+
+// Run command and read how long the computer has been idle from its output,
+// either milliseconds like xprintidle prints or a duration like "90s"
+func commandIdleTime(command string) (time.Duration, error) {
+	output, err := shellCommand(command).Output()
+	if err != nil {
+		return 0, err
+	}
+	text := strings.TrimSpace(string(output))
+	if ms, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	idle, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, errors.New("expected milliseconds or a duration, got \"" + text + "\"")
+	}
+	return idle, nil
+}
+
+// Pause work and ask about the idle time once it reaches idle_timeout. The
+// question waits for an open overlay, like the settings form, to be closed.
+// Must be called with the lock held
+func (self *session) checkIdle(idle time.Duration) {
+	self.showIdlePrompt()
+	if self.cfg.IdleTimeout == 0 || self.tmr.TimerState() != timer.WORK ||
+		idle < time.Duration(self.cfg.IdleTimeout)*time.Second {
+		return
+	}
+	seconds := int(idle / time.Second)
+	if seconds > self.tmr.Counter() {
+		seconds = self.tmr.Counter()
+	}
+	self.tmr.Pause()
+	prompt := idlePrompt{seconds: seconds, phaseStart: self.current.start}
+	self.idleConfirm = &confirmation{
+		question: "Away for " + timer.ShortTimeString(seconds) + ". Keep it as work?",
+		action:   func() { self.answerIdle(prompt, true) },
+		declined: func() { self.answerIdle(prompt, false) },
+	}
+	self.showIdlePrompt()
+}
+
+// Ask about idle time found earlier once no other overlay is open. Must be
+// called with the lock held
+func (self *session) showIdlePrompt() {
+	if self.idleConfirm == nil || self.overlay != NO_OVERLAY {
+		return
+	}
+	self.confirm = self.idleConfirm
+	self.idleConfirm = nil
+	self.overlay = CONFIRM_OVERLAY
+}
+
+// Take the idle time off unless it is kept, then carry on working. Must be
+// called with the lock held
+func (self *session) answerIdle(prompt idlePrompt, keep bool) {
+	if self.current.phase == "" || !self.current.start.Equal(prompt.phaseStart) {
+		return
+	}
+	if !keep {
+		self.current.away += self.tmr.DiscardWork(prompt.seconds)
+	}
+	if self.tmr.TimerState() == timer.WORK_PAUSED {
+		self.tmr.Start()
+	}
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestIdleDiscarded(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.IdleTimeout = 2
+	s.act(ACTION_START)
+	s.tickAndRecord(2)
+	s.checkIdle(time.Second)
+	if s.tmr.TimerState() != timer.WORK {
+		t.Fatal("Expected work to go on before the timeout. Got:", s.tmr.TimerState())
+	}
+	s.checkIdle(5 * time.Second)
+	if s.tmr.TimerState() != timer.WORK_PAUSED || s.overlay != CONFIRM_OVERLAY {
+		t.Fatal("Expected work to be paused with a prompt. Got:", s.tmr.TimerState(), s.overlay)
+	}
+	if s.confirm.question != "Away for 2s. Keep it as work?" {
+		t.Error("Expected no more than the work done to be asked about. Got:", s.confirm.question)
+	}
+	s.handle(view.Input{Key: keys.RuneKey('n')})
+	s.record()
+	if s.tmr.TimerState() != timer.WORK || s.tmr.Counter() != 0 {
+		t.Error("Expected work to resume without the idle time. Got:", s.tmr.TimerState(), s.tmr.Counter())
+	}
+	s.tickAndRecord(4)
+	s.act(ACTION_SKIP)
+	s.act(ACTION_START)
+	s.act(ACTION_SKIP)
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) == 0 || entries[0].Away != 2 || entries[0].Paused != 2 || entries[0].Actual != 3 {
+		t.Error("Expected 2s away to be recorded. Got:", entries)
+	}
+}
+
+func TestIdleKept(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.IdleTimeout = 1
+	s.act(ACTION_START)
+	s.tickAndRecord(2)
+	s.checkIdle(time.Second)
+	s.handle(view.Input{Key: keys.RuneKey('y')})
+	s.record()
+	if s.tmr.TimerState() != timer.WORK || s.tmr.Counter() != 2 || s.current.away != 0 {
+		t.Error("Expected the idle time to count as work. Got:", s.tmr.Counter(), s.current.away)
+	}
+	s.cfg.IdleTimeout = 0
+	s.checkIdle(time.Hour)
+	if s.tmr.TimerState() != timer.WORK {
+		t.Error("Expected no idle detection with a 0 timeout. Got:", s.tmr.TimerState())
+	}
+}
+
+func TestIdlePromptWaitsForOverlay(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.IdleTimeout = 1
+	s.act(ACTION_START)
+	s.tickAndRecord(2)
+	s.act(ACTION_SETTINGS)
+	s.checkIdle(time.Second)
+	if s.tmr.TimerState() != timer.WORK_PAUSED || s.overlay != SETTINGS_OVERLAY || s.settings == nil {
+		t.Fatal("Expected work to pause behind the open settings. Got:", s.tmr.TimerState(), s.overlay)
+	}
+	s.handle(view.Input{Key: keys.Key{Code: tcell.KeyEscape}})
+	if s.overlay != CONFIRM_OVERLAY || s.confirm.question != "Away for 1s. Keep it as work?" {
+		t.Error("Expected the idle prompt once the settings are closed. Got:", s.overlay)
+	}
+}
+
+func TestCommandIdleTime(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Needs sh")
+	}
+	for command, expected := range map[string]time.Duration{
+		"echo 90500": 90500 * time.Millisecond,
+		"echo 2m":    2 * time.Minute,
+	} {
+		idle, err := commandIdleTime(command)
+		if err != nil || idle != expected {
+			t.Error("Expected", expected, "from", command, "Got:", idle, err)
+		}
+	}
+	if _, err := commandIdleTime("echo soon"); err == nil {
+		t.Error("Expected output that isn't a time to fail")
+	}
+	if _, err := commandIdleTime("exit 1"); err == nil {
+		t.Error("Expected a failing command to fail")
+	}
+}
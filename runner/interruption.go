@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"pomodoro/history"
+	"pomodoro/view"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Optional note for an interruption that was just logged
+type noteForm struct {
+	kind string
+	// Start of the work phase the interruption belongs to
+	phaseStart time.Time
+	text       string
+}
+
+func (self *noteForm) Text() string {
+	title := "Internal interruption logged"
+	if self.kind == history.INTERRUPTION_EXTERNAL {
+		title = "External interruption logged"
+	}
+	return title + "\n\nNote: " + self.text + "_\n\nEnter: Save  Esc: No note"
+}
+
+// Log an interruption of the work phase in progress. Without a note the note
+// form is opened. Must be called with the lock held
+func (self *session) interrupt(kind, note string) {
+	if phaseOf(self.tmr.TimerState()) != history.PHASE_WORK {
+		return
+	}
+	interruption := history.Interruption{Kind: kind, Time: time.Now(), Note: note}
+	self.current.interruptions = append(self.current.interruptions, interruption)
+	self.interruptions = append(self.interruptions, interruption)
+	if note == "" {
+		self.note = &noteForm{kind: kind, phaseStart: self.current.start}
+		self.overlay = NOTE_OVERLAY
+	}
+}
+
+// In line mode the whole line is the note, even if it looks like a command,
+// and an empty line leaves the interruption without one
+func (self *session) handleNoteInput(input view.Input) {
+	form := self.note
+	key := input.Key
+	switch {
+	case input.FromLine:
+		form.text = input.Line
+		self.saveNote()
+	case key.Code == tcell.KeyEscape:
+		self.closeOverlay()
+	case key.Code == tcell.KeyEnter:
+		self.saveNote()
+	case key.Code == tcell.KeyBackspace || key.Code == tcell.KeyBackspace2:
+		_, size := utf8.DecodeLastRuneInString(form.text)
+		form.text = form.text[:len(form.text)-size]
+	case key.Code == tcell.KeyRune && key.Mod&(tcell.ModCtrl|tcell.ModAlt) == 0:
+		form.text += string(key.Char)
+	}
+}
+
+// Add the note to the last interruption, unless its phase already ended
+func (self *session) saveNote() {
+	form := self.note
+	self.closeOverlay()
+	current := self.current.interruptions
+	if form.text == "" || len(current) == 0 || !self.current.start.Equal(form.phaseStart) {
+		return
+	}
+	current[len(current)-1].Note = form.text
+	self.interruptions[len(self.interruptions)-1].Note = form.text
+}
+
+// Start the session over. The phase in progress is recorded as unfinished
+// first, since the reset would drop it. Must be called with the lock held
+func (self *session) restart() {
+	self.record()
+	self.endPhase()
+	self.tmr.Reset()
+	self.planDay(time.Now())
+	self.interruptions = nil
+}
+
+func countInterruptions(interruptions []history.Interruption) view.Interruptions {
+	internal, external := history.CountInterruptions(interruptions)
+	return view.Interruptions{Internal: internal, External: external}
+}
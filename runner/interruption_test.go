@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"pomodoro/history"
+	"pomodoro/keys"
+	"pomodoro/lineui"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestLogInterruptions(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.act(ACTION_INTERNAL_INTERRUPTION)
+	if s.overlay != NOTE_OVERLAY {
+		t.Fatal("Expected the note form to open. Got:", s.overlay)
+	}
+	for _, key := range []keys.Key{
+		keys.RuneKey('m'), keys.RuneKey('a'), keys.RuneKey('i'), keys.RuneKey('x'),
+		{Code: tcell.KeyBackspace2}, keys.RuneKey('l'), {Code: tcell.KeyEnter},
+	} {
+		s.handle(view.Input{Key: key})
+	}
+	if s.overlay != NO_OVERLAY {
+		t.Error("Expected Enter to close the note form. Got:", s.overlay)
+	}
+	s.handle(view.Input{Action: ACTION_EXTERNAL_INTERRUPTION, Text: "phone call"})
+	if s.overlay != NO_OVERLAY {
+		t.Error("Expected no note form for a note given with the action. Got:", s.overlay)
+	}
+	if model := s.model(); model.Interruptions != (view.Interruptions{Internal: 1, External: 1}) {
+		t.Error("Expected 1 internal and 1 external interruption. Got:", model.Interruptions)
+	}
+	s.tickAndRecord(4)
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) != 1 || len(entries[0].Interruptions) != 2 {
+		t.Fatal("Expected the interruptions in the history. Got:", entries)
+	}
+	for i, expected := range []history.Interruption{
+		{Kind: history.INTERRUPTION_INTERNAL, Note: "mail"},
+		{Kind: history.INTERRUPTION_EXTERNAL, Note: "phone call"},
+	} {
+		got := entries[0].Interruptions[i]
+		if got.Kind != expected.Kind || got.Note != expected.Note {
+			t.Error("Expected", expected, "Got:", got)
+		}
+	}
+	s.act(ACTION_START)
+	s.act(ACTION_INTERNAL_INTERRUPTION)
+	if s.overlay != NO_OVERLAY || s.model().Interruptions.Total() != 0 {
+		t.Error("Expected interruptions to be ignored during a break. Got:", s.model().Interruptions)
+	}
+	s.tmr.Stop()
+	s.record()
+	text := s.model().TimerText()
+	if !strings.HasSuffix(text, ". Interruptions: 1 internal, 1 external") {
+		t.Error("Expected the interruptions in the done text. Got:", text)
+	}
+	s.restart()
+	if s.model().TotalInterruptions.Total() != 0 {
+		t.Error("Expected a restart to clear the interruptions. Got:", s.model().TotalInterruptions)
+	}
+}
+
+func TestNoteIsDroppedAfterPhaseEnds(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.act(ACTION_EXTERNAL_INTERRUPTION)
+	s.tickAndRecord(4)
+	s.handle(view.Input{FromLine: true, Line: "too late"})
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) != 1 || entries[0].Interruptions[0].Note != "" {
+		t.Error("Expected the interruption without a note. Got:", entries)
+	}
+	if s.overlay != NO_OVERLAY {
+		t.Error("Expected the note form to close. Got:", s.overlay)
+	}
+}
+
+// In line mode the line after the interruption is the note, whatever it says,
+// and the lines after that are commands again
+func TestLineModeNote(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	bindings := s.keys
+	for _, line := range []string{"'", "p", "-", "", "p"} {
+		input := view.Input{FromLine: true, Line: line}
+		if line != "" {
+			input = lineui.ParseCommand(line, bindings)
+			input.FromLine = true
+			input.Line = line
+		}
+		s.handle(input)
+	}
+	if s.overlay != NO_OVERLAY || s.tmr.TimerState() != timer.WORK_PAUSED {
+		t.Error("Expected the last line to pause. Got:", s.overlay, s.tmr.TimerState())
+	}
+	interruptions := s.current.interruptions
+	if len(interruptions) != 2 || interruptions[0].Note != "p" || interruptions[1].Note != "" {
+		t.Error("Expected a note of p and an interruption without a note. Got:", interruptions)
+	}
+}
@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"errors"
+	"pomodoro/keys"
+	"sort"
+)
+
+const (
+	ACTION_START    = "start"
+	ACTION_PAUSE    = "pause"
+	ACTION_SKIP     = "skip"
+	ACTION_QUIT     = "quit"
+	ACTION_HELP     = "help"
+	ACTION_SETTINGS = "settings"
+	ACTION_RESET    = "reset"
+	ACTION_RESTART  = "restart"
+	ACTION_END      = "end"
+	ACTION_VOID     = "void"
+	ACTION_PROFILES = "profiles"
+	// Log an interruption of the work phase in progress
+	ACTION_INTERNAL_INTERRUPTION = "internal_interruption"
+	ACTION_EXTERNAL_INTERRUPTION = "external_interruption"
+)
+
+// Order in which actions are listed in the help text
+var ACTIONS = []string{
+	ACTION_START,
+	ACTION_PAUSE,
+	ACTION_SKIP,
+	ACTION_RESET,
+	ACTION_RESTART,
+	ACTION_END,
+	ACTION_VOID,
+	ACTION_INTERNAL_INTERRUPTION,
+	ACTION_EXTERNAL_INTERRUPTION,
+	ACTION_SETTINGS,
+	ACTION_PROFILES,
+	ACTION_HELP,
+	ACTION_QUIT,
+}
+
+var actionLabels = map[string]string{
+	ACTION_START:                 "Start/Resume",
+	ACTION_PAUSE:                 "Pause",
+	ACTION_SKIP:                  "Skip",
+	ACTION_RESET:                 "Reset current phase",
+	ACTION_RESTART:               "Restart session",
+	ACTION_END:                   "End session early",
+	ACTION_VOID:                  "Void current pomodoro",
+	ACTION_INTERNAL_INTERRUPTION: "Log an internal interruption",
+	ACTION_EXTERNAL_INTERRUPTION: "Log an external interruption",
+	ACTION_SETTINGS:              "Settings",
+	ACTION_PROFILES:              "Profiles",
+	ACTION_HELP:                  "Help",
+	ACTION_QUIT:                  "Quit",
+}
+
+type KeyBindings map[string][]string
+
+func DefaultKeyBindings() KeyBindings {
+	return KeyBindings{
+		ACTION_START:                 {"s"},
+		ACTION_PAUSE:                 {"p"},
+		ACTION_SKIP:                  {"k"},
+		ACTION_QUIT:                  {"q", "Esc"},
+		ACTION_HELP:                  {"?"},
+		ACTION_SETTINGS:              {"o"},
+		ACTION_RESET:                 {"r"},
+		ACTION_RESTART:               {"R"},
+		ACTION_END:                   {"e"},
+		ACTION_VOID:                  {"v"},
+		ACTION_PROFILES:              {"P"},
+		ACTION_INTERNAL_INTERRUPTION: {"'"},
+		ACTION_EXTERNAL_INTERRUPTION: {"-"},
+	}
+}
+
+func isAction(action string) bool {
+	for _, a := range ACTIONS {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse the bindings and check for unknown actions, invalid keys and keys
+// bound to more than one action
+func (self KeyBindings) Parse() (bindings keys.Map, err error) {
+	bindings = keys.Map{}
+	usedBy := map[keys.Key]string{}
+	actions := make([]string, 0, len(self))
+	for action := range self {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		if !isAction(action) {
+			return nil, errors.New("Unknown action \"" + action + "\" in key bindings")
+		}
+		names := self[action]
+		if len(names) == 0 {
+			return nil, errors.New("No keys bound to action \"" + action + "\"")
+		}
+		for _, name := range names {
+			key, err := keys.ParseKey(name)
+			if err != nil {
+				return nil, errors.New(err.Error() + " bound to action \"" + action + "\"")
+			}
+			if other, ok := usedBy[key]; ok {
+				return nil, errors.New(
+					"Key " + key.String() + " is bound to both \"" + other +
+						"\" and \"" + action + "\"",
+				)
+			}
+			usedBy[key] = action
+			bindings[action] = append(bindings[action], key)
+		}
+	}
+	return bindings, nil
+}
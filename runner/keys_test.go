@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"pomodoro/keys"
+	"testing"
+)
+
+func TestParseDefaultKeyBindings(t *testing.T) {
+	bindings, err := DefaultKeyBindings().Parse()
+	if err != nil {
+		t.Error("Expected default key bindings to be valid. Got:", err)
+	}
+	if len(bindings[ACTION_QUIT]) != 2 {
+		t.Error("Expected 2 quit keys. Got:", len(bindings[ACTION_QUIT]))
+	}
+}
+
+func TestParseSpecialKeys(t *testing.T) {
+	bindings := DefaultKeyBindings()
+	bindings[ACTION_START] = []string{"Space"}
+	bindings[ACTION_PAUSE] = []string{"enter"}
+	parsed, err := bindings.Parse()
+	if err != nil {
+		t.Error("Expected key bindings to be valid. Got:", err)
+	}
+	if parsed[ACTION_START][0] != keys.RuneKey(' ') {
+		t.Error("Expected start key to be ' '. Got:", parsed[ACTION_START][0])
+	}
+	if parsed.Names(ACTION_PAUSE) != "Enter" {
+		t.Error("Expected pause key to be Enter. Got:", parsed.Names(ACTION_PAUSE))
+	}
+}
+
+func TestParseInvalidKeyBindings(t *testing.T) {
+	bindings := DefaultKeyBindings()
+	bindings[ACTION_SKIP] = []string{"s"}
+	if _, err := bindings.Parse(); err == nil {
+		t.Error("Expected conflicting key bindings to be rejected")
+	}
+	bindings = DefaultKeyBindings()
+	bindings[ACTION_SKIP] = []string{"sk"}
+	if _, err := bindings.Parse(); err == nil {
+		t.Error("Expected invalid key to be rejected")
+	}
+	bindings = DefaultKeyBindings()
+	bindings["jump"] = []string{"j"}
+	if _, err := bindings.Parse(); err == nil {
+		t.Error("Expected unknown action to be rejected")
+	}
+}
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"pomodoro/view"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Screens drawn over the timer. While one is open every input goes to it
+// instead of the timer
+const (
+	NO_OVERLAY = iota
+	HELP_OVERLAY
+	SETTINGS_OVERLAY
+	CONFIRM_OVERLAY
+	PROFILES_OVERLAY
+	NOTE_OVERLAY
+)
+
+// A yes/no question asked before an action that loses progress
+type confirmation struct {
+	question string
+	action   func()
+	// Run when the answer is no. May be nil
+	declined func()
+}
+
+func (self *session) openHelp() {
+	self.overlay = HELP_OVERLAY
+}
+
+func (self *session) openProfiles() {
+	self.overlay = PROFILES_OVERLAY
+}
+
+func (self *session) openSettings() {
+	if self.tmr.TimerState() == timer.DONE {
+		return
+	}
+	self.settings = newSettingsForm(self.tmr)
+	self.overlay = SETTINGS_OVERLAY
+}
+
+// Ask before running the response
+func (self *session) confirmed(question string, response func()) func() {
+	return func() {
+		self.confirm = &confirmation{question: question, action: response}
+		self.overlay = CONFIRM_OVERLAY
+	}
+}
+
+func (self *session) closeOverlay() {
+	self.overlay = NO_OVERLAY
+	self.settings = nil
+	self.confirm = nil
+	self.note = nil
+}
+
+func (self *session) handleOverlay(input view.Input) {
+	switch self.overlay {
+	case HELP_OVERLAY, PROFILES_OVERLAY:
+		// Any key closes the help and the profile list
+		self.closeOverlay()
+	case CONFIRM_OVERLAY:
+		// Only 'y' and Enter confirm, any other key cancels
+		confirm := self.confirm
+		self.closeOverlay()
+		key := input.Key
+		if key == keys.RuneKey('y') || key == keys.RuneKey('Y') ||
+			key.Code == tcell.KeyEnter {
+			confirm.action()
+		} else if confirm.declined != nil {
+			confirm.declined()
+		}
+	case SETTINGS_OVERLAY:
+		self.handleSettingsKey(input.Key)
+	case NOTE_OVERLAY:
+		self.handleNoteInput(input)
+	}
+}
+
+func (self *session) overlayText() string {
+	switch self.overlay {
+	case HELP_OVERLAY:
+		return helpText(self.keys)
+	case SETTINGS_OVERLAY:
+		return self.settings.Text()
+	case PROFILES_OVERLAY:
+		return self.cfg.ProfilesText()
+	case CONFIRM_OVERLAY:
+		return self.confirm.question + "\n\n'y': Yes\nAny other key: No"
+	case NOTE_OVERLAY:
+		return self.note.Text()
+	default:
+		return ""
+	}
+}
+
+func helpText(bindings keys.Map) string {
+	text := "Keys\n\n"
+	for _, action := range ACTIONS {
+		text += bindings.Names(action) + ": " + actionLabels[action] + "\n"
+	}
+	text += "\nPress any key to close"
+	return text
+}
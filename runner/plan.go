@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"errors"
+	"pomodoro/history"
+	"pomodoro/timer"
+	"time"
+)
+
+// A phase of a planned session with its clock times
+type PlannedPhase struct {
+	// One of the history.PHASE_ values
+	Phase string
+	Start time.Time
+	End   time.Time
+}
+
+// As many pomodoros as fit between a start and a target end
+type SessionPlan struct {
+	Phases    []PlannedPhase
+	Pomodoros int
+	Target    time.Time
+}
+
+// The end of the last phase, or the zero time if there are none
+func (self SessionPlan) End() time.Time {
+	if len(self.Phases) == 0 {
+		return time.Time{}
+	}
+	return self.Phases[len(self.Phases)-1].End
+}
+
+// Fit as many pomodoros as possible between start and end with the phase
+// lengths and long break interval of the config. A pomodoro that would start
+// during lunch starts after it. The last pomodoro isn't followed by a break
+func (self *Config) Plan(start, end time.Time) (SessionPlan, error) {
+	plan := SessionPlan{Target: end}
+	lunch, hasLunch := self.lunch()
+	work := time.Duration(self.WorkTime) * time.Second
+	now := start
+	for i := 0; ; i++ {
+		if hasLunch {
+			lunchStart, lunchEnd := lunch.on(now)
+			if !now.Before(lunchStart) && now.Before(lunchEnd) {
+				now = lunchEnd
+			}
+		}
+		if now.Add(work).After(end) {
+			break
+		}
+		if i > 0 {
+			phase, length := self.breakAfter(i - 1)
+			breakStart := plan.End()
+			plan.Phases = append(plan.Phases, PlannedPhase{phase, breakStart, breakStart.Add(length)})
+		}
+		plan.Phases = append(plan.Phases, PlannedPhase{history.PHASE_WORK, now, now.Add(work)})
+		plan.Pomodoros++
+		_, length := self.breakAfter(i)
+		now = now.Add(work).Add(length)
+	}
+	if plan.Pomodoros == 0 {
+		return plan, errors.New("No pomodoro fits before " + end.Format(CLOCK_LAYOUT))
+	}
+	return plan, nil
+}
+
+// The break after the pomodoro at index i, counting from 0, picked the way the
+// timer does
+func (self *Config) breakAfter(i int) (phase string, length time.Duration) {
+	if i == 0 || (i+1)%self.LongBreakInterval != 0 {
+		return history.PHASE_SHORT_BREAK, time.Duration(self.BreakTime) * time.Second
+	}
+	return history.PHASE_LONG_BREAK, time.Duration(self.LongBreakTime) * time.Second
+}
+
+// Run the planned number of pomodoros and show the projected end against the
+// target. The number is kept with the command line values so a reload doesn't
+// undo it
+func (self *Config) ApplyPlan(plan SessionPlan) {
+	if self.args == nil {
+		self.args = &configArgs{}
+	}
+	self.args.totalPomodoros = plan.Pomodoros
+	self.TotalPomodoros = plan.Pomodoros
+	self.setSource("total_pomodoros", SOURCE_ARGS)
+	self.PlanTarget = plan.Target
+}
+
+// e.g. "Ends at 16:55, target 17:00". "" when the session wasn't planned
+func (self *session) projectedEnd(now time.Time) string {
+	if self.cfg.PlanTarget.IsZero() || self.tmr.TimerState() == timer.DONE {
+		return ""
+	}
+	end := now.Add(time.Duration(self.tmr.RemainingTime()) * time.Second)
+	return "Ends at " + end.Format(CLOCK_LAYOUT) + ", target " + self.cfg.PlanTarget.Format(CLOCK_LAYOUT)
+}
+
+// The target end of a session from --until, a time like "17:00" that is
+// tomorrow if it has already passed, or --for, a duration like "3h". Returns
+// the zero time when neither is given
+func PlanEnd(until, length string, start time.Time) (time.Time, error) {
+	if until != "" && length != "" {
+		return time.Time{}, errors.New("Use either --until or --for, not both")
+	}
+	if length != "" {
+		d, err := ParseDuration(length)
+		if err != nil {
+			return time.Time{}, errors.New("--for: " + err.Error())
+		}
+		return start.Add(time.Duration(d) * time.Second), nil
+	}
+	if until == "" {
+		return time.Time{}, nil
+	}
+	minutes, err := parseClock(until)
+	if err != nil {
+		return time.Time{}, errors.New("--until: " + err.Error() + ", got \"" + until + "\"")
+	}
+	end := history.StartOfDay(start).Add(time.Duration(minutes) * time.Minute)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end, nil
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"pomodoro/history"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	cfg := defaultConfig("config.json")
+	cfg.WorkTime, cfg.BreakTime, cfg.LongBreakTime, cfg.LongBreakInterval = 1500, 300, 900, 4
+	plan, err := cfg.Plan(at(9, 0), at(12, 0))
+	if err != nil {
+		t.Fatal("Expected a plan. Got:", err)
+	}
+	if plan.Pomodoros != 5 {
+		t.Error("Expected 5 pomodoros. Got:", plan.Pomodoros)
+	}
+	if len(plan.Phases) != 9 || plan.Phases[7].Phase != history.PHASE_LONG_BREAK {
+		t.Error("Expected a long break after the fourth pomodoro. Got:", plan.Phases)
+	}
+	if !plan.End().Equal(at(11, 35)) {
+		t.Error("Expected to be done at 11:35. Got:", plan.End())
+	}
+}
+
+func TestPlanSkipsLunch(t *testing.T) {
+	cfg := defaultConfig("config.json")
+	cfg.WorkTime, cfg.BreakTime = 1500, 300
+	cfg.Lunch = "12:00-13:00"
+	plan, err := cfg.Plan(at(11, 30), at(14, 0))
+	if err != nil {
+		t.Fatal("Expected a plan. Got:", err)
+	}
+	if plan.Pomodoros != 3 || !plan.Phases[2].Start.Equal(at(13, 0)) {
+		t.Error("Expected the second pomodoro after lunch. Got:", plan.Phases)
+	}
+	if !plan.Phases[1].End.Equal(at(12, 0)) {
+		t.Error("Expected the break before lunch to be kept. Got:", plan.Phases[1])
+	}
+}
+
+func TestPlanNothingFits(t *testing.T) {
+	cfg := defaultConfig("config.json")
+	cfg.WorkTime = 1500
+	if _, err := cfg.Plan(at(9, 0), at(9, 20)); err == nil || err.Error() != "No pomodoro fits before 09:20" {
+		t.Error("Expected no pomodoro to fit. Got:", err)
+	}
+}
+
+func TestPlanEnd(t *testing.T) {
+	end, err := PlanEnd("17:00", "", at(9, 0))
+	if err != nil || !end.Equal(at(17, 0)) {
+		t.Error("Expected 17:00 today. Got:", end, err)
+	}
+	end, err = PlanEnd("", "2h30m", at(9, 0))
+	if err != nil || !end.Equal(at(11, 30)) {
+		t.Error("Expected 11:30. Got:", end, err)
+	}
+	if _, err := PlanEnd("17:00", "3h", at(9, 0)); err == nil {
+		t.Error("Expected --until and --for together to fail")
+	}
+}
+
+func TestProjectedEnd(t *testing.T) {
+	s := newRecordSession(t)
+	if text := s.projectedEnd(at(9, 0)); text != "" {
+		t.Error("Expected no projected end without a plan. Got:", text)
+	}
+	s.cfg.PlanTarget = at(9, 1)
+	if text := s.projectedEnd(at(9, 0).Add(-8 * time.Second)); text != "Ends at 09:00, target 09:01" {
+		t.Error("Expected the projected end. Got:", text)
+	}
+}
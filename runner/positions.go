@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Where the value of each field starts, keyed like "profiles.deep work.work_time".
+// Fields that can't be found are left out
+func keyPositions(path string, data []byte) map[string]position {
+	positions := map[string]position{}
+	switch configFormat(path) {
+	case FORMAT_TOML:
+		tomlPositions(data, positions)
+	case FORMAT_YAML:
+		var node yaml.Node
+		if yaml.Unmarshal(data, &node) == nil {
+			yamlPositions(&node, "", positions)
+		}
+	default:
+		jsonPositions(data, positions)
+	}
+	return positions
+}
+
+// Line and column, both starting at 1, of a byte offset
+func offsetPosition(data []byte, offset int) position {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len([]rune(string(before[bytes.LastIndexByte(before, '\n')+1:]))) + 1
+	return position{line, column}
+}
+
+func jsonPositions(data []byte, positions map[string]position) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				token, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				key := joinKey(prefix, token.(string))
+				// Skip past the colon to the start of the value
+				offset := int(decoder.InputOffset())
+				for offset < len(data) && strings.ContainsRune(" \t\r\n:", rune(data[offset])) {
+					offset++
+				}
+				positions[key] = offsetPosition(data, offset)
+				err = walk(key)
+				if err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for decoder.More() {
+				err = walk(prefix)
+				if err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+		return err
+	}
+	walk("")
+}
+
+func yamlPositions(node *yaml.Node, prefix string, positions map[string]position) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			yamlPositions(child, prefix, positions)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := joinKey(prefix, node.Content[i].Value)
+			value := node.Content[i+1]
+			positions[key] = position{value.Line, value.Column}
+			yamlPositions(value, key, positions)
+		}
+	}
+}
+
+// Good enough for config files: tables, array tables and key = value lines
+func tomlPositions(data []byte, positions map[string]position) {
+	table := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			header := strings.Trim(trimmed, "[] \t")
+			if end := strings.Index(header, "]"); end >= 0 {
+				header = header[:end]
+			}
+			table = strings.Join(splitTomlKey(header), ".")
+			column := strings.Index(line, "[") + 1
+			positions[table] = position{i + 1, column}
+			continue
+		}
+		equals := strings.Index(line, "=")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || equals < 0 {
+			continue
+		}
+		key := strings.Join(splitTomlKey(line[:equals]), ".")
+		column := equals + 1
+		for column < len(line) && (line[column] == ' ' || line[column] == '\t') {
+			column++
+		}
+		positions[joinKey(table, key)] = position{i + 1, len([]rune(line[:column])) + 1}
+	}
+}
+
+// Split a dotted key like `profiles."deep work"` into its parts
+func splitTomlKey(key string) []string {
+	parts := []string{}
+	part := ""
+	quote := rune(0)
+	for _, r := range strings.TrimSpace(key) {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			part += string(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(part))
+			part = ""
+		default:
+			part += string(r)
+		}
+	}
+	return append(parts, strings.TrimSpace(part))
+}
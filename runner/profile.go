@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// Overrides for any config field, keyed like the config file, e.g.
+// {"work_time": 50, "break_time": 10}
+type Profile map[string]json.RawMessage
+
+// Copy with its own key bindings so decoding into it doesn't change self
+func (self Config) clone() Config {
+	bindings := KeyBindings{}
+	for action, names := range self.KeyBindings {
+		bindings[action] = append([]string{}, names...)
+	}
+	self.KeyBindings = bindings
+	sources := map[string]string{}
+	for key, source := range self.sources {
+		sources[key] = source
+	}
+	self.sources = sources
+	return self
+}
+
+// The config with the overrides of a profile applied
+func (self Config) withProfile(profile Profile) (cfg Config, err error) {
+	cfg = self.clone()
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return self, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	return
+}
+
+// Apply the named profile, or the default profile if name is "". The values
+// read from the file are kept so the other profiles can be listed
+func (self *Config) applyProfile(name, configPath string) (err error) {
+	base := self.clone()
+	self.base = &base
+	if name == "" {
+		name = self.DefaultProfile
+	}
+	if name == "" {
+		return
+	}
+	profile, ok := self.Profiles[name]
+	if !ok {
+		errMsg := "Profile \"" + name + "\" not found in the " + configPath + " file.\n"
+		errMsg += "Using the base config...\n"
+		return errors.New(errMsg)
+	}
+	cfg, err := self.withProfile(profile)
+	if err != nil {
+		errMsg := "Failed to read profile \"" + name + "\": " + err.Error() + ".\n"
+		errMsg += "Using the base config...\n"
+		return errors.New(errMsg)
+	}
+	if data, err := json.Marshal(profile); err == nil {
+		cfg.setSources(data, func(string) string { return "profile \"" + name + "\"" })
+	}
+	cfg.profile = name
+	*self = cfg
+	return
+}
+
+// Name of the profile in use, or "" if none
+func (self *Config) Profile() string {
+	return self.profile
+}
+
+// Names of all profiles in alphabetical order
+func (self *Config) ProfileNames() []string {
+	names := make([]string, 0, len(self.Profiles))
+	for name := range self.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// One line per profile with its times, marking the active and default ones
+func (self *Config) ProfilesText() string {
+	base := *self
+	if self.base != nil {
+		base = *self.base
+	}
+	text := "Profiles\n\n"
+	if len(self.Profiles) == 0 {
+		text += "No profiles in " + self.path + "\n"
+	}
+	for _, name := range self.ProfileNames() {
+		marker := "  "
+		if name == self.profile {
+			marker = "* "
+		}
+		line := marker + name
+		if name == self.DefaultProfile {
+			line += " (default)"
+		}
+		cfg, err := base.withProfile(self.Profiles[name])
+		if err != nil {
+			text += line + ": invalid\n"
+			continue
+		}
+		line += ": " + cfg.WorkTime.String() + " work, " +
+			cfg.BreakTime.String() + " break, " +
+			cfg.LongBreakTime.String() + " long break, " +
+			strconv.Itoa(cfg.TotalPomodoros) + " pomodoros"
+		text += line + "\n"
+	}
+	text += "\nStart with --profile <name> to use a profile\n"
+	text += "Press any key to close"
+	return text
+}
+
+// Fields of the config as they are written to the file
+func fieldMap(cfg Config) (fields map[string]json.RawMessage, err error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &fields)
+	return
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const PROFILE_CONFIG = `{
+  "work_time": 25,
+  "break_time": 5,
+  "total_pomodoros": 8,
+  "default_profile": "classic",
+  "profiles": {
+    "classic": {},
+    "deep work": {"work_time": 50, "break_time": 10},
+    "meetings day": {"work_time": 15, "break_time": 3, "total_pomodoros": 4}
+  }
+}`
+
+func writeProfileConfig(t *testing.T) string {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, DEFAULT_SOUND_PATH), []byte{}, 0644)
+	path := filepath.Join(dir, "config.json")
+	err := os.WriteFile(path, []byte(PROFILE_CONFIG), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewConfigProfile(t *testing.T) {
+	path := writeProfileConfig(t)
+	cfg, errs := NewConfig(path, "deep work")
+	if len(errs) > 0 {
+		t.Error("Expected no errors. Got:", errs)
+	}
+	if cfg.WorkTime != 50*60 || cfg.BreakTime != 10*60 {
+		t.Error("Expected 50/10 from the profile. Got:", cfg.WorkTime, cfg.BreakTime)
+	}
+	if cfg.TotalPomodoros != 8 || cfg.LongBreakTime != DEFAULT_LONG_BREAK_TIME {
+		t.Error("Expected the rest from the base and defaults. Got:",
+			cfg.TotalPomodoros, cfg.LongBreakTime)
+	}
+	cfg.ReadArgs("45", "", "", 0, 0, false)
+	if cfg.WorkTime != 45*60 || cfg.BreakTime != 10*60 {
+		t.Error("Expected arguments to override the profile. Got:", cfg.WorkTime, cfg.BreakTime)
+	}
+
+	cfg, _ = NewConfig(path, "")
+	if cfg.Profile() != "classic" || cfg.WorkTime != 25*60 {
+		t.Error("Expected the default profile. Got:", cfg.Profile(), cfg.WorkTime)
+	}
+	cfg, errs = NewConfig(path, "nope")
+	if len(errs) != 1 || cfg.Profile() != "" || cfg.WorkTime != 25*60 {
+		t.Error("Expected an error and the base config. Got:", errs, cfg.WorkTime)
+	}
+}
+
+func TestSaveProfile(t *testing.T) {
+	path := writeProfileConfig(t)
+	cfg, _ := NewConfig(path, "meetings day")
+	err := cfg.SaveValues(map[string]string{"break_time": "4m", "long_break_interval": "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	saved := map[string]json.RawMessage{}
+	json.Unmarshal(data, &saved)
+	if string(saved["break_time"]) != "5" {
+		t.Error("Expected the base config to be unchanged. Got:", string(saved["break_time"]))
+	}
+
+	cfg, _ = NewConfig(path, "meetings day")
+	if cfg.WorkTime != 15*60 || cfg.BreakTime != 4*60 || cfg.LongBreakInterval != 2 {
+		t.Error("Expected the changes in the profile. Got:",
+			cfg.WorkTime, cfg.BreakTime, cfg.LongBreakInterval)
+	}
+	cfg, _ = NewConfig(path, "classic")
+	if cfg.BreakTime != 5*60 || cfg.LongBreakInterval != DEFAULT_LONG_BREAK_INTERVAL {
+		t.Error("Expected other profiles to be unchanged. Got:", cfg.BreakTime, cfg.LongBreakInterval)
+	}
+}
+
+func TestProfilesText(t *testing.T) {
+	cfg, _ := NewConfig(writeProfileConfig(t), "deep work")
+	text := cfg.ProfilesText()
+	for _, line := range []string{
+		"  classic (default): 25m work, 5m break, 15m long break, 8 pomodoros",
+		"* deep work: 50m work, 10m break, 15m long break, 8 pomodoros",
+		"  meetings day: 15m work, 3m break, 15m long break, 4 pomodoros",
+	} {
+		if !strings.Contains(text, line) {
+			t.Error("Expected the profile list to contain", line, "Got:", text)
+		}
+	}
+}
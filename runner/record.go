@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"pomodoro/history"
+	"pomodoro/timer"
+	"time"
+)
+
+// The phase in progress, added to the history when it ends
+type phaseRecord struct {
+	// "" when no phase is running
+	phase         string
+	start         time.Time
+	planned       int
+	elapsed       int
+	paused        int
+	waiting       int
+	interruptions []history.Interruption
+	// Set when the pomodoro was voided
+	abandoned bool
+	// Idle seconds taken off the phase
+	away int
+}
+
+func phaseOf(state timer.TimerState) string {
+	switch state {
+	case timer.WORK, timer.WORK_PAUSED:
+		return history.PHASE_WORK
+	case timer.SBREAK, timer.SBREAK_PAUSED:
+		return history.PHASE_SHORT_BREAK
+	case timer.LBREAK, timer.LBREAK_PAUSED:
+		return history.PHASE_LONG_BREAK
+	default:
+		return ""
+	}
+}
+
+// Length of a phase in seconds
+func plannedLength(tmr *timer.Timer, phase string) int {
+	switch phase {
+	case history.PHASE_WORK:
+		return tmr.MaxWorkCounter()
+	case history.PHASE_SHORT_BREAK:
+		return tmr.MaxSbreakCounter()
+	default:
+		return tmr.MaxLbreakCounter()
+	}
+}
+
+// Whether state is where a phase goes back to when it is reset
+func resetState(phase string, state timer.TimerState) bool {
+	switch state {
+	case timer.STOPPED:
+		return true
+	case timer.PRE_WORK:
+		return phase == history.PHASE_WORK
+	case timer.PRE_SBREAK:
+		return phase == history.PHASE_SHORT_BREAK
+	case timer.PRE_LBREAK:
+		return phase == history.PHASE_LONG_BREAK
+	}
+	return false
+}
+
+// Follow the timer and add each phase to the history when it ends. Reset
+// phases aren't recorded unless they were voided. Must be called with the
+// lock held after anything that can change the timer
+func (self *session) record() {
+	state := self.tmr.TimerState()
+	phase := phaseOf(state)
+	current := &self.current
+	if current.phase != "" && phase == current.phase {
+		current.elapsed = self.tmr.Counter()
+		current.paused = self.tmr.PhasePausedTime()
+		self.checkGoals()
+		return
+	}
+	if current.phase != "" && (current.abandoned || !resetState(current.phase, state)) {
+		self.endPhase()
+	}
+	self.current = phaseRecord{}
+	self.activity = ""
+	if phase == history.PHASE_SHORT_BREAK || phase == history.PHASE_LONG_BREAK {
+		self.suggestActivity(phase)
+	}
+	if phase == history.PHASE_WORK {
+		self.activeAt = time.Now()
+	}
+	if phase != "" {
+		self.current = phaseRecord{
+			phase:   phase,
+			start:   time.Now(),
+			planned: plannedLength(self.tmr, phase),
+			elapsed: self.tmr.Counter(),
+			paused:  self.tmr.PhasePausedTime(),
+			waiting: self.tmr.PhaseWaitingTime(),
+		}
+	}
+	self.checkGoals()
+}
+
+// Add the phase in progress to the history, e.g. when the session is quit
+// during it. Must be called with the lock held
+func (self *session) endPhase() {
+	current := self.current
+	if current.phase == "" {
+		return
+	}
+	self.current = phaseRecord{}
+	self.addHistory(history.Entry{
+		Phase:         current.phase,
+		Start:         current.start,
+		End:           time.Now(),
+		Planned:       current.planned,
+		Actual:        current.elapsed,
+		Paused:        current.paused,
+		Waiting:       current.waiting,
+		Completed:     !current.abandoned && current.elapsed >= current.planned,
+		Abandoned:     current.abandoned,
+		Away:          current.away,
+		Task:          self.cfg.Task,
+		Interruptions: current.interruptions,
+	})
+}
+
+// Discard the pomodoro in progress. It is recorded as abandoned and the next
+// one waits to be started. Must be called with the lock held
+func (self *session) void() {
+	if phaseOf(self.tmr.TimerState()) != history.PHASE_WORK {
+		return
+	}
+	self.current.elapsed = self.tmr.Counter()
+	self.current.paused = self.tmr.PhasePausedTime()
+	self.current.abandoned = true
+	self.tmr.Void()
+}
+
+// Where phases are recorded. nil in test mode so test runs don't show up in
+// the stats and goals
+func (self *Config) historyStore() *history.Store {
+	if self.testMode {
+		return nil
+	}
+	return history.NewStore(self.HistoryPath())
+}
+
+func (self *session) addHistory(entry history.Entry) {
+	if self.history == nil {
+		return
+	}
+	err := self.history.Append(entry)
+	if err != nil {
+		self.notify("Failed to record history: " + err.Error())
+		return
+	}
+	self.addProgress(entry)
+}
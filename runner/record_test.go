@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"path/filepath"
+	"pomodoro/history"
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"testing"
+	"time"
+)
+
+// A session with a 3s work phase, 2s breaks and its history in a temp file
+func newRecordSession(t *testing.T) *session {
+	cfg := defaultConfig(filepath.Join(t.TempDir(), "config.json"))
+	cfg.Task = "Write tests"
+	tmr := timer.NewTimer(3, 2, 2, 4, 2, false)
+	headless := view.NewHeadless()
+	s := newSession(&cfg, tmr, headless, headless)
+	s.history = history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	return s
+}
+
+func (self *session) tickAndRecord(times int) {
+	for i := 0; i < times; i++ {
+		self.tmr.Tick()
+		self.record()
+	}
+}
+
+func (self *session) act(action string) {
+	self.handle(view.Input{Action: action})
+	self.record()
+}
+
+func TestRecordPhases(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.tickAndRecord(4)
+	s.act(ACTION_START)
+	s.tickAndRecord(1)
+	s.act(ACTION_SKIP)
+	entries, err := s.history.Read(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatal("Expected 2 entries. Got:", entries)
+	}
+	work := entries[0]
+	if work.Phase != history.PHASE_WORK || !work.Completed || work.Actual != 3 || work.Planned != 3 {
+		t.Error("Expected a completed 3s work phase. Got:", work)
+	}
+	if work.Task != "Write tests" || work.End.Before(work.Start) {
+		t.Error("Expected the task and an end after the start. Got:", work)
+	}
+	skipped := entries[1]
+	if skipped.Phase != history.PHASE_SHORT_BREAK || skipped.Completed || skipped.Actual != 1 {
+		t.Error("Expected a skipped short break after 1s. Got:", skipped)
+	}
+}
+
+func TestResetPhaseIsNotRecorded(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.tickAndRecord(2)
+	s.tmr.ResetPhase()
+	s.record()
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) != 0 {
+		t.Error("Expected no entries after a reset. Got:", entries)
+	}
+	if s.current.phase != "" {
+		t.Error("Expected no phase in progress. Got:", s.current)
+	}
+}
+
+func TestRestartRecordsPhaseInProgress(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.tickAndRecord(2)
+	s.restart()
+	s.record()
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) != 1 || entries[0].Completed || entries[0].Actual != 2 {
+		t.Error("Expected an unfinished work phase after 2s. Got:", entries)
+	}
+	if s.current.phase != "" {
+		t.Error("Expected no phase in progress. Got:", s.current)
+	}
+}
+
+func TestQuitRecordsPhaseInProgress(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.tickAndRecord(1)
+	s.act(ACTION_PAUSE)
+	s.quit()
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) != 1 || entries[0].Completed || entries[0].Actual != 1 {
+		t.Error("Expected an unfinished work phase. Got:", entries)
+	}
+}
+
+func TestRecordPausedAndWaitingTime(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.tickAndRecord(1)
+	s.act(ACTION_PAUSE)
+	s.tickAndRecord(2)
+	s.act(ACTION_START)
+	s.tickAndRecord(3)
+	s.tickAndRecord(2)
+	s.act(ACTION_START)
+	s.act(ACTION_SKIP)
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) != 2 {
+		t.Fatal("Expected 2 entries. Got:", entries)
+	}
+	if entries[0].Paused != 2 || entries[0].Waiting != 0 {
+		t.Error("Expected the work phase to be paused for 2s. Got:", entries[0])
+	}
+	if entries[1].Paused != 0 || entries[1].Waiting != 2 {
+		t.Error("Expected 2s waiting before the break. Got:", entries[1])
+	}
+}
+
+func TestVoidRecordsAbandonedPomodoro(t *testing.T) {
+	s := newRecordSession(t)
+	s.act(ACTION_START)
+	s.tickAndRecord(2)
+	s.act(ACTION_VOID)
+	if s.overlay != CONFIRM_OVERLAY {
+		t.Fatal("Expected a confirmation prompt. Got:", s.overlay)
+	}
+	s.handle(view.Input{Key: keys.RuneKey('y')})
+	s.record()
+	if s.tmr.TimerState() != timer.STOPPED || s.tmr.WorkIter() != 0 {
+		t.Error("Expected the pomodoro not to count. Got:", s.tmr.TimerState(), s.tmr.WorkIter())
+	}
+	entries, _ := s.history.Read(time.Time{}, time.Time{})
+	if len(entries) != 1 {
+		t.Fatal("Expected 1 entry. Got:", entries)
+	}
+	if !entries[0].Abandoned || entries[0].Completed || entries[0].Actual != 2 {
+		t.Error("Expected an abandoned pomodoro after 2s. Got:", entries[0])
+	}
+	if s.current.phase != "" {
+		t.Error("Expected no phase in progress. Got:", s.current)
+	}
+}
+
+func TestTestModeNotRecorded(t *testing.T) {
+	cfg := defaultConfig(filepath.Join(t.TempDir(), "config.json"))
+	if cfg.historyStore() == nil {
+		t.Error("Expected a history store")
+	}
+	cfg.TestMode()
+	if store := cfg.historyStore(); store != nil {
+		t.Error("Expected no history in test mode. Got:", store)
+	}
+}
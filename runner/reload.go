@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// How often the config file is checked for changes
+	RELOAD_INTERVAL = time.Second
+	// How long a message stays on screen
+	MESSAGE_DURATION = 5 * time.Second
+)
+
+// Fields that only take effect when a session starts. Changing them in the
+// file is reported instead of applied
+var restartFields = []string{"long_break_interval", "auto_start", "total_pomodoros"}
+
+// Tells whether a file changed since it was last looked at
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// The zero stamp if the file can't be read
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{info.ModTime(), info.Size()}
+}
+
+// Reload the config when the file changes or the process gets SIGHUP. Returns
+// once the session is quit
+func (self *session) watchConfig() {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+	ticker := time.NewTicker(RELOAD_INTERVAL)
+	defer ticker.Stop()
+	self.mu.Lock()
+	self.configStamp = statFile(self.cfg.Path())
+	self.mu.Unlock()
+	for {
+		select {
+		case <-self.done:
+			return
+		case <-hangups:
+			self.mu.Lock()
+			self.configStamp = statFile(self.cfg.Path())
+			self.reload()
+			self.mu.Unlock()
+		case <-ticker.C:
+			self.mu.Lock()
+			self.reloadIfChanged()
+			self.mu.Unlock()
+		}
+	}
+}
+
+// Must be called with the lock held
+func (self *session) reloadIfChanged() {
+	stamp := statFile(self.cfg.Path())
+	if stamp == self.configStamp {
+		return
+	}
+	self.configStamp = stamp
+	self.reload()
+}
+
+// Read the config again and apply what can change mid-session: markers,
+// sounds, key bindings, the skip policy and the lengths of phases that haven't
+// started yet. Only values that changed since the config was last read are
+// applied, so changes made in the app are kept. A file with errors is rejected
+// as a whole. Must be called with the lock held
+func (self *session) reload() {
+	cfg, errs := self.cfg.reload()
+	if len(errs) > 0 {
+		text := "Config not reloaded: " + firstLine(errs[0].Error())
+		if len(errs) > 1 {
+			text += " (and " + strconv.Itoa(len(errs)-1) + " more)"
+		}
+		self.notify(text)
+		return
+	}
+	changed := changedFields(self.loaded, cfg)
+	self.loaded = cfg
+	rejected := []string{}
+	for _, field := range restartFields {
+		if changed[field] {
+			rejected = append(rejected, field)
+		}
+		// Keep what the running session was started with
+		changed[field] = false
+	}
+	for _, key := range fieldKeys() {
+		if !changed[key] {
+			copyField(&cfg, self.cfg, key)
+		}
+	}
+
+	self.markers.WorkChar = cfg.WorkChar
+	self.markers.BreakChar = cfg.BreakChar
+	self.markers.EmptyChar = cfg.EmptyChar
+	if self.player != nil {
+		self.player.SetSoundPaths(cfg.SoundPaths())
+		self.player.SetVolume(cfg.Volume)
+	}
+	if changed["key_bindings"] {
+		self.keys = cfg.KeyMap()
+		self.input.SetBindings(self.keys)
+	}
+	self.tmr.CountSkippedWork = cfg.CountSkippedWork
+	if changed["work_time"] || changed["break_time"] || changed["long_break_time"] {
+		self.tmr.SetFutureDurations(
+			int(cfg.WorkTime), int(cfg.BreakTime), int(cfg.LongBreakTime),
+		)
+	}
+	*self.cfg = cfg
+	self.loadActivities()
+	text := "Config reloaded"
+	if len(rejected) > 0 {
+		text += ". Restart to apply " + strings.Join(rejected, ", ")
+	}
+	self.notify(text)
+}
+
+// Fields of the config file that differ between a and b
+func changedFields(a, b Config) map[string]bool {
+	changed := map[string]bool{}
+	before, err := fieldMap(a)
+	if err != nil {
+		return changed
+	}
+	after, err := fieldMap(b)
+	if err != nil {
+		return changed
+	}
+	for key, value := range after {
+		if !bytes.Equal(value, before[key]) {
+			changed[key] = true
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed[key] = true
+		}
+	}
+	return changed
+}
+
+// Copy the value written to the file as key from src to dst
+func copyField(dst, src *Config, key string) {
+	configType := reflect.TypeOf(*dst)
+	for i := 0; i < configType.NumField(); i++ {
+		if strings.Split(configType.Field(i).Tag.Get("json"), ",")[0] == key {
+			reflect.ValueOf(dst).Elem().Field(i).Set(reflect.ValueOf(src).Elem().Field(i))
+			return
+		}
+	}
+}
+
+// Show a message for MESSAGE_DURATION. Must be called with the lock held
+func (self *session) notify(text string) {
+	self.message = text
+	self.messageUntil = time.Now().Add(MESSAGE_DURATION)
+}
+
+func (self *session) currentMessage() string {
+	if time.Now().After(self.messageUntil) {
+		return ""
+	}
+	return self.message
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
@@ -0,0 +1,201 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"pomodoro/keys"
+	"pomodoro/player"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"strings"
+	"testing"
+	"time"
+)
+
+const RELOAD_CONFIG = `{
+	"work_time": "25m",
+	"break_time": "5m",
+	"total_pomodoros": 8,
+	"pomodoro_char": "X"
+}`
+
+func newReloadSession(t *testing.T) (*session, *view.Headless, string) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, DEFAULT_SOUND_PATH), []byte{}, 0644)
+	path := filepath.Join(dir, "config.json")
+	err := os.WriteFile(path, []byte(RELOAD_CONFIG), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, errs := NewConfig(path, "")
+	if len(errs) > 0 {
+		t.Fatal("Expected no errors. Got:", errs)
+	}
+	tmr := timer.NewTimer(
+		int(cfg.WorkTime), int(cfg.BreakTime), int(cfg.LongBreakTime),
+		cfg.TotalPomodoros, cfg.LongBreakInterval, cfg.AutoStart,
+	)
+	headless := view.NewHeadless()
+	s := newSession(&cfg, tmr, headless, headless)
+	s.configStamp = statFile(path)
+	return s, headless, path
+}
+
+// Write the file so its stamp changes even on coarse timestamps
+func rewrite(t *testing.T, path, data string) {
+	err := os.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+}
+
+func TestReloadAppliesSafeChanges(t *testing.T) {
+	s, headless, path := newReloadSession(t)
+	s.handle(view.Input{Action: ACTION_START})
+	s.tmr.Tick()
+	rewrite(t, path, `{
+		"work_time": "50m",
+		"break_time": "10m",
+		"total_pomodoros": 4,
+		"pomodoro_char": "Y",
+		"key_bindings": {"start": ["g"]}
+	}`)
+	s.reloadIfChanged()
+	s.render()
+	if s.tmr.MaxWorkCounter() != 25*60 || s.tmr.MaxSbreakCounter() != 10*60 {
+		t.Error("Expected the running work phase to keep 25m and the break to be 10m. Got:",
+			s.tmr.MaxWorkCounter(), s.tmr.MaxSbreakCounter())
+	}
+	if s.tmr.TimerState() != timer.WORK || s.tmr.Counter() != 1 {
+		t.Error("Expected the work phase to go on. Got:", s.tmr.TimerState(), s.tmr.Counter())
+	}
+	model := headless.Last()
+	if model.Markers.WorkChar != "Y" {
+		t.Error("Expected the new marker Y. Got:", model.Markers.WorkChar)
+	}
+	if headless.Bindings().Action(keys.RuneKey('g')) != ACTION_START {
+		t.Error("Expected g to start the timer. Got:", headless.Bindings())
+	}
+	if s.cfg.TotalPomodoros != 8 || s.tmr.MaxWorkIter() != 8 {
+		t.Error("Expected total_pomodoros to stay 8. Got:", s.cfg.TotalPomodoros)
+	}
+	if model.Message != "Config reloaded. Restart to apply total_pomodoros" {
+		t.Error("Expected total_pomodoros to be rejected. Got:", model.Message)
+	}
+	s.handle(view.Input{Action: ACTION_SKIP})
+	s.handle(view.Input{Action: ACTION_SKIP})
+	if s.tmr.MaxWorkCounter() != 50*60 {
+		t.Error("Expected the next work phase to be 50m. Got:", s.tmr.MaxWorkCounter())
+	}
+}
+
+func TestReloadRejectsInvalidConfig(t *testing.T) {
+	s, headless, path := newReloadSession(t)
+	rewrite(t, path, `{"work_time": "50m", "pomodoro_char": "Y", "break_time": 0}`)
+	s.reloadIfChanged()
+	s.render()
+	model := headless.Last()
+	if !strings.HasPrefix(model.Message, "Config not reloaded: ") ||
+		!strings.Contains(model.Message, "break_time") {
+		t.Error("Expected break_time to be reported. Got:", model.Message)
+	}
+	if s.cfg.WorkTime != 25*60 || model.Markers.WorkChar != "X" {
+		t.Error("Expected nothing to change. Got:", s.cfg.WorkTime, model.Markers.WorkChar)
+	}
+	// Unchanged since the last check
+	s.message = ""
+	s.reloadIfChanged()
+	if s.currentMessage() != "" {
+		t.Error("Expected no reload. Got:", s.currentMessage())
+	}
+}
+
+func TestReloadKeepsArgs(t *testing.T) {
+	s, _, path := newReloadSession(t)
+	s.cfg.ReadArgs("40m", "", "", 0, 0, false)
+	rewrite(t, path, `{"work_time": "50m", "break_time": "10m"}`)
+	s.reloadIfChanged()
+	if s.cfg.WorkTime != 40*60 || s.cfg.BreakTime != 10*60 {
+		t.Error("Expected 40m from the flag and a 10m break. Got:", s.cfg.WorkTime, s.cfg.BreakTime)
+	}
+}
+
+func TestReloadKeepsSessionValues(t *testing.T) {
+	s, _, path := newReloadSession(t)
+	s.cfg.Task = "Write the report"
+	s.cfg.StartAt = time.Now().Add(time.Hour)
+	s.cfg.PlanTarget = time.Now().Add(5 * time.Hour)
+	rewrite(t, path, `{"work_time": "50m"}`)
+	s.reloadIfChanged()
+	if s.cfg.Task != "Write the report" || s.cfg.StartAt.IsZero() || s.cfg.PlanTarget.IsZero() {
+		t.Error("Expected the session values to be kept. Got:", s.cfg.Task, s.cfg.StartAt, s.cfg.PlanTarget)
+	}
+	if text := s.projectedEnd(time.Now()); !strings.HasPrefix(text, "Ends at ") {
+		t.Error("Expected the projected end to be shown. Got:", text)
+	}
+}
+
+func TestReloadKeepsSettingsChangedInApp(t *testing.T) {
+	s, headless, path := newReloadSession(t)
+	form := newSettingsForm(s.tmr)
+	form.fields[FIELD_WORK_TIME].value = "40m"
+	form.fields[FIELD_TOTAL_POMODOROS].value = "6"
+	err := form.apply(s.cfg, s.tmr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrite(t, path, `{
+		"work_time": "25m",
+		"break_time": "10m",
+		"total_pomodoros": 8,
+		"pomodoro_char": "Y"
+	}`)
+	s.reloadIfChanged()
+	s.render()
+	if s.cfg.WorkTime != 40*60 || s.tmr.MaxWorkCounter() != 40*60 || s.cfg.TotalPomodoros != 6 {
+		t.Error("Expected the values from the app to be kept. Got:", s.cfg.WorkTime, s.cfg.TotalPomodoros)
+	}
+	if s.cfg.BreakTime != 10*60 || headless.Last().Markers.WorkChar != "Y" {
+		t.Error("Expected the changes in the file to be applied. Got:", s.cfg.BreakTime)
+	}
+	if message := headless.Last().Message; message != "Config reloaded" {
+		t.Error("Expected no restart to be asked for. Got:", message)
+	}
+}
+
+func TestReloadKeepsPlan(t *testing.T) {
+	_, _, path := newReloadSession(t)
+	cfg, _ := NewConfig(path, "")
+	cfg.ApplyPlan(SessionPlan{Pomodoros: 3, Target: time.Now().Add(2 * time.Hour)})
+	tmr := timer.NewTimer(int(cfg.WorkTime), int(cfg.BreakTime), int(cfg.LongBreakTime), cfg.TotalPomodoros, cfg.LongBreakInterval, false)
+	headless := view.NewHeadless()
+	s := newSession(&cfg, tmr, headless, headless)
+	rewrite(t, path, `{"work_time": "25m", "break_time": "5m", "total_pomodoros": 8, "pomodoro_char": "Y"}`)
+	s.reloadIfChanged()
+	s.render()
+	if s.cfg.TotalPomodoros != 3 {
+		t.Error("Expected the planned 3 pomodoros to be kept. Got:", s.cfg.TotalPomodoros)
+	}
+	if message := headless.Last().Message; message != "Config reloaded" {
+		t.Error("Expected no restart to be asked for. Got:", message)
+	}
+}
+
+func TestReloadAppliesVolume(t *testing.T) {
+	s, _, path := newReloadSession(t)
+	p := player.NewPlayer(s.cfg.SoundPaths())
+	s.player = &p
+	rewrite(t, path, `{
+	"work_time": "25m",
+	"break_time": "5m",
+	"total_pomodoros": 8,
+	"pomodoro_char": "X",
+	"volume": 40
+}`)
+	s.reloadIfChanged()
+	if s.cfg.Volume != 40 || p.Volume() != 40 {
+		t.Error("Expected the volume to be 40. Got:", s.cfg.Volume, p.Volume())
+	}
+}
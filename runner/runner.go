@@ -1,146 +1,410 @@
 package runner
 
 import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"pomodoro/history"
+	"pomodoro/keys"
+	"pomodoro/lineui"
 	"pomodoro/player"
 	"pomodoro/tcellui"
 	"pomodoro/timer"
+	"pomodoro/view"
 	"sync"
 	"time"
+
+	"golang.org/x/term"
 )
 
-type Markers struct {
-	WorkChar  string
-	BreakChar string
-	EmptyChar string
+// Everything the update loop and the input loop share
+type session struct {
+	mu        sync.Mutex
+	cfg       *Config
+	tmr       *timer.Timer
+	renderer  view.Renderer
+	input     view.InputSource
+	markers   view.Markers
+	keys      keys.Map
+	responses map[timer.TimerState]map[string]func()
+	overlay   int
+	settings  *settingsForm
+	confirm   *confirmation
+	note      *noteForm
+	done      chan struct{}
+	quitOnce  sync.Once
+	wg        *sync.WaitGroup
+	// nil when there is no sound, e.g. in tests
+	player *player.Player
+	// nil when nothing is recorded, e.g. in tests
+	history  *history.Store
+	current  phaseRecord
+	progress goalProgress
+	// Logged since the session was started or restarted
+	interruptions []history.Interruption
+	// Break activities keyed by phase, and the one suggested for the break in
+	// progress
+	activities map[string]*activityDeck
+	activity   string
+	rng        *rand.Rand
+	// Last sign of someone at the computer, for idle detection without
+	// idle_command
+	activeAt time.Time
+	// Question about idle time waiting for the overlay in front of it to close
+	idleConfirm *confirmation
+	// Where the session waits for a set time. workdayEnd is zero when the
+	// session doesn't end with the working hours
+	scheduledFor   time.Time
+	scheduleReason string
+	skippedUntil   time.Time
+	workdayEnd     time.Time
+	// nil when the session can't be controlled from other terminals
+	controlListener net.Listener
+	controlPath     string
+	// The config as it was last read, without the changes made in the app
+	loaded       Config
+	configStamp  fileStamp
+	message      string
+	messageUntil time.Time
 }
 
 func Run(wg *sync.WaitGroup, cfg *Config) {
-	p := player.NewPlayer(cfg.WorkSoundPath, cfg.BreakSoundPath)
+	p := player.NewPlayer(cfg.SoundPaths())
+	p.SetVolume(cfg.Volume)
 	tmr := timer.NewTimer(
-		cfg.WorkTime,
-		cfg.BreakTime,
-		cfg.LongBreakTime,
+		int(cfg.WorkTime),
+		int(cfg.BreakTime),
+		int(cfg.LongBreakTime),
 		cfg.TotalPomodoros,
 		cfg.LongBreakInterval,
 		cfg.AutoStart,
 	)
-	markers := &Markers{
-		WorkChar:  cfg.WorkChar,
-		BreakChar: cfg.BreakChar,
-		EmptyChar: cfg.EmptyChar,
+	tmr.CountSkippedWork = cfg.CountSkippedWork
+	renderer, input := newFrontend(cfg.KeyMap())
+	s := newSession(cfg, tmr, renderer, input)
+	s.player = &p
+	s.history = cfg.historyStore()
+	s.loadProgress()
+	s.planDay(time.Now())
+	if !fileExists(cfg.Path()) {
+		s.notify("No config file, using the defaults. Create one with pomodoro config init")
+	}
+	err := s.serveControl(ControlPath())
+	if err != nil {
+		s.notify("Can't be controlled from other terminals: " + err.Error())
+	}
+	s.start(wg)
+	go s.watchConfig()
+	go s.watchIdle()
+	go s.soundLoop()
+}
+
+// Use the full screen ui when possible and fall back to printing lines
+func newFrontend(bindings keys.Map) (view.Renderer, view.InputSource) {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		ui := lineui.NewLineUI(os.Stdin, os.Stdout, bindings, false)
+		return ui, ui
+	}
+	ui, err := tcellui.NewTcellUI(bindings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error()+". Falling back to line mode")
+		ui := lineui.NewLineUI(os.Stdin, os.Stdout, bindings, true)
+		return ui, ui
+	}
+	return ui, ui
+}
+
+func newSession(
+	cfg *Config,
+	tmr *timer.Timer,
+	renderer view.Renderer,
+	input view.InputSource,
+) *session {
+	s := &session{
+		cfg:      cfg,
+		tmr:      tmr,
+		renderer: renderer,
+		input:    input,
+		markers: view.Markers{
+			WorkChar:  cfg.WorkChar,
+			BreakChar: cfg.BreakChar,
+			EmptyChar: cfg.EmptyChar,
+		},
+		keys:     cfg.KeyMap(),
+		overlay:  NO_OVERLAY,
+		done:     make(chan struct{}),
+		activeAt: time.Now(),
+		loaded:   *cfg,
 	}
-	ui := tcellui.NewTcellUI(0)
-	updateText(ui, tmr, markers)
-	addEventResponses(ui, tmr)
-	go ui.Listen(wg)
-	go updateLoop(tmr, ui, markers)
-	go soundLoop(tmr, &p)
+	s.addEventResponses()
+	s.loadProgress()
+	s.loadActivities()
+	return s
+}
+
+// Start listening for input and updating the timer. wg.Done is called once
+// the session is quit
+func (self *session) start(wg *sync.WaitGroup) {
+	self.wg = wg
+	inputs := make(chan view.Input)
+	self.render()
+	go func() {
+		self.input.Listen(inputs)
+		self.quit()
+	}()
+	go self.inputLoop(inputs)
+	go self.updateLoop()
+}
+
+func (self *session) quit() {
+	self.quitOnce.Do(func() {
+		close(self.done)
+		self.mu.Lock()
+		self.record()
+		self.endPhase()
+		self.input.Close()
+		self.renderer.Close()
+		self.mu.Unlock()
+		// Before wg.Done, since the program exits right after it
+		if self.controlListener != nil {
+			self.controlListener.Close()
+			os.Remove(self.controlPath)
+		}
+		if self.wg != nil {
+			self.wg.Done()
+		}
+	})
 }
 
-func updateLoop(tmr *timer.Timer, ui *tcellui.TcellUI, markers *Markers) {
+func (self *session) updateLoop() {
 	updateRate := 100 * time.Millisecond
 	threshold := 10
 	counter := 0
 	for {
-		ui.Update()
+		select {
+		case <-self.done:
+			return
+		default:
+		}
+		self.mu.Lock()
 		if counter >= threshold {
-			tmr.Tick()
+			self.tmr.Tick()
+			self.followSchedule(time.Now())
 			counter = 0
 		}
-		updateText(ui, tmr, markers)
-		ui.AppState = int(tmr.TimerState())
+		self.record()
+		self.render()
+		self.mu.Unlock()
 		time.Sleep(updateRate)
 		counter += 1
 	}
 }
 
-func soundLoop(tmr *timer.Timer, p *player.Player) {
+func (self *session) inputLoop(inputs <-chan view.Input) {
+	for {
+		select {
+		case input := <-inputs:
+			self.mu.Lock()
+			self.activeAt = time.Now()
+			if input.Action == ACTION_QUIT && self.overlay == NO_OVERLAY {
+				self.mu.Unlock()
+				self.quit()
+				return
+			}
+			self.handle(input)
+			self.record()
+			self.render()
+			self.mu.Unlock()
+		case <-self.done:
+			return
+		}
+	}
+}
+
+// Must be called with the lock held
+func (self *session) handle(input view.Input) {
+	// An empty line only answers the note prompt
+	if input.FromLine && input.Line == "" && self.overlay != NOTE_OVERLAY {
+		return
+	}
+	if self.overlay != NO_OVERLAY {
+		self.handleOverlay(input)
+		self.showIdlePrompt()
+		return
+	}
+	switch input.Action {
+	case ACTION_HELP:
+		self.openHelp()
+		return
+	case ACTION_SETTINGS:
+		self.openSettings()
+		return
+	case ACTION_PROFILES:
+		self.openProfiles()
+		return
+	case ACTION_INTERNAL_INTERRUPTION:
+		self.interrupt(history.INTERRUPTION_INTERNAL, input.Text)
+		return
+	case ACTION_EXTERNAL_INTERRUPTION:
+		self.interrupt(history.INTERRUPTION_EXTERNAL, input.Text)
+		return
+	}
+	if response, ok := self.responses[self.tmr.TimerState()][input.Action]; ok {
+		response()
+	}
+}
+
+func (self *session) render() {
+	self.renderer.Render(self.model())
+}
+
+func (self *session) model() view.Model {
+	tmr := self.tmr
+	state := tmr.TimerState()
+	return view.Model{
+		State:              state,
+		Remaining:          remaining(tmr),
+		Iterations:         tmr.WorkIter(),
+		MaxIterations:      tmr.MaxWorkIter(),
+		RemainingBreaks:    tmr.RemainingBreaks(),
+		TotalWorkTime:      tmr.TotalWorkTime(),
+		TotalBreakTime:     tmr.TotalBreakTime(),
+		TotalPausedTime:    tmr.TotalPausedTime(),
+		TotalWaitingTime:   tmr.TotalWaitingTime(),
+		Aborted:            tmr.Aborted(),
+		Markers:            self.markers,
+		Keys:               keyHints(state, self.keys),
+		Buttons:            buttons(state),
+		Task:               self.cfg.Task,
+		Goals:              self.goals(),
+		Activity:           self.activity,
+		Schedule:           self.scheduleText(),
+		ProjectedEnd:       self.projectedEnd(time.Now()),
+		Interruptions:      countInterruptions(self.current.interruptions),
+		TotalInterruptions: countInterruptions(self.interruptions),
+		Message:            self.currentMessage(),
+		Overlay:            self.overlayText(),
+	}
+}
+
+// Play the alarm when a phase runs out. The timer is only read with the lock
+// held. Returns once the session is quit
+func (self *session) soundLoop() {
 	updateRate := 100 * time.Millisecond
-	prevState := tmr.TimerState()
+	self.mu.Lock()
+	prevState := self.tmr.TimerState()
+	self.mu.Unlock()
 	for {
+		select {
+		case <-self.done:
+			return
+		case <-time.After(updateRate):
+		}
+		self.mu.Lock()
+		state := self.tmr.TimerState()
+		autoAdvance := self.tmr.AutoAdvance
+		aborted := self.tmr.Aborted()
+		self.mu.Unlock()
 		if prevState == timer.WORK {
-			state := tmr.TimerState()
-			autoAdvChecks := tmr.AutoAdvance && (state == timer.SBREAK ||
+			autoAdvChecks := autoAdvance && (state == timer.SBREAK ||
 				state == timer.LBREAK ||
 				state == timer.DONE)
-			manualAdvChecks := !tmr.AutoAdvance && (state == timer.PRE_SBREAK ||
+			manualAdvChecks := !autoAdvance && (state == timer.PRE_SBREAK ||
 				state == timer.PRE_LBREAK ||
 				state == timer.DONE)
-			if autoAdvChecks || manualAdvChecks {
-				prevState = tmr.TimerState()
-				p.PlayWork()
+			// No alarm when the session is ended early
+			if state == timer.DONE && aborted {
+				prevState = state
+			} else if autoAdvChecks || manualAdvChecks {
+				prevState = state
+				self.player.PlayWork()
 			}
 		} else if prevState == timer.SBREAK || prevState == timer.LBREAK {
-			autoAdvChecks := tmr.AutoAdvance && tmr.TimerState() == timer.WORK
-			manualAdvChecks := !tmr.AutoAdvance && tmr.TimerState() == timer.PRE_WORK
+			autoAdvChecks := autoAdvance && state == timer.WORK
+			manualAdvChecks := !autoAdvance && state == timer.PRE_WORK
 			if autoAdvChecks || manualAdvChecks {
-				prevState = tmr.TimerState()
-				p.PlayBreak()
+				prevState = state
+				self.player.PlayBreak()
 			}
 		} else {
-			prevState = tmr.TimerState()
+			prevState = state
 		}
-		time.Sleep(updateRate)
 	}
 }
 
-func updateText(ui *tcellui.TcellUI, tmr *timer.Timer, m *Markers) {
-	ui.Text = pomoDoroString(tmr, m.WorkChar, m.EmptyChar)
-	ui.Text += breakString(tmr, m.BreakChar)
-	state := tmr.TimerState()
-	ui.Text += timerText(state, tmr)
-	ui.Text += keyText(state)
-}
-
-func keyText(state timer.TimerState) string {
+func keyHints(state timer.TimerState, bindings keys.Map) []view.KeyHint {
+	hint := func(action, label string) view.KeyHint {
+		return view.KeyHint{Keys: bindings.Names(action), Label: label}
+	}
+	skip := hint(ACTION_SKIP, "Skip")
+	help := hint(ACTION_HELP, "Help")
+	quit := hint(ACTION_QUIT, "Quit")
 	switch state {
 	case timer.WORK, timer.SBREAK, timer.LBREAK:
-		return "'p': Pause\n'k': Skip\n'q': Quit"
+		return []view.KeyHint{hint(ACTION_PAUSE, "Pause"), skip, help, quit}
 	case timer.WORK_PAUSED, timer.SBREAK_PAUSED, timer.LBREAK_PAUSED:
-		return "'s': Resume\n'k': Skip\n'q': Quit"
+		return []view.KeyHint{hint(ACTION_START, "Resume"), skip, help, quit}
 	case timer.PRE_SBREAK, timer.PRE_LBREAK:
-		return "'s': Start Break\n'k': Skip\n'q': Quit"
+		return []view.KeyHint{hint(ACTION_START, "Start Break"), skip, help, quit}
 	case timer.PRE_WORK, timer.STOPPED:
-		return "'s': Start Work\n'k': Skip\n'q': Quit"
+		return []view.KeyHint{hint(ACTION_START, "Start Work"), skip, help, quit}
+	case timer.SCHEDULED:
+		return []view.KeyHint{hint(ACTION_START, "Start Now"), help, quit}
 	case timer.DONE:
-		return "Press 'q' to quit"
+		return []view.KeyHint{hint(ACTION_RESTART, "Restart"), help, quit}
 	default:
-		return ""
+		return []view.KeyHint{}
 	}
 }
 
-func timerText(state timer.TimerState, tmr *timer.Timer) string {
+// Clickable buttons for the actions available in each state
+func buttons(state timer.TimerState) []view.Button {
+	skip := view.Button{Label: "Skip", Action: ACTION_SKIP}
 	switch state {
+	case timer.WORK, timer.SBREAK, timer.LBREAK:
+		return []view.Button{{Label: "Pause", Action: ACTION_PAUSE}, skip}
+	case timer.WORK_PAUSED, timer.SBREAK_PAUSED, timer.LBREAK_PAUSED:
+		return []view.Button{{Label: "Resume", Action: ACTION_START}, skip}
+	case timer.PRE_SBREAK, timer.PRE_LBREAK:
+		return []view.Button{{Label: "Start Break", Action: ACTION_START}, skip}
+	case timer.PRE_WORK:
+		return []view.Button{{Label: "Start Work", Action: ACTION_START}, skip}
+	case timer.STOPPED:
+		return []view.Button{{Label: "Start Work", Action: ACTION_START}}
+	case timer.SCHEDULED:
+		return []view.Button{{Label: "Start Now", Action: ACTION_START}}
+	default:
+		return []view.Button{}
+	}
+}
+
+// Seconds left in the current phase
+func remaining(tmr *timer.Timer) int {
+	switch tmr.TimerState() {
 	case timer.PRE_WORK, timer.STOPPED:
-		return "Work: " + tmr.TimeString(tmr.MaxWorkCounter()) + "\n\n"
+		return tmr.MaxWorkCounter()
 	case timer.WORK, timer.WORK_PAUSED:
-		return "Work: " + tmr.TimeString(
-			tmr.MaxWorkCounter()-tmr.Counter(),
-		) + "\n\n"
+		return tmr.MaxWorkCounter() - tmr.Counter()
 	case timer.PRE_SBREAK:
-		return "Short Break: " + tmr.TimeString(tmr.MaxSbreakCounter()) + "\n\n"
+		return tmr.MaxSbreakCounter()
 	case timer.SBREAK, timer.SBREAK_PAUSED:
-		return "Short Break: " + tmr.TimeString(
-			tmr.MaxSbreakCounter()-tmr.Counter(),
-		) + "\n\n"
+		return tmr.MaxSbreakCounter() - tmr.Counter()
 	case timer.PRE_LBREAK:
-		return "Long Break: " + tmr.TimeString(tmr.MaxLbreakCounter()) + "\n\n"
+		return tmr.MaxLbreakCounter()
 	case timer.LBREAK, timer.LBREAK_PAUSED:
-		return "Long Break: " + tmr.TimeString(
-			tmr.MaxLbreakCounter()-tmr.Counter(),
-		) + "\n\n"
-	case timer.DONE:
-		text := "Done! You worked for " + tmr.TimeString(tmr.TotalWorkTime())
-		text += " and took breaks for " + tmr.TimeString(tmr.TotalBreakTime()) + "\n\n"
-		return text
+		return tmr.MaxLbreakCounter() - tmr.Counter()
+	case timer.SCHEDULED:
+		return tmr.ScheduleLeft()
 	default:
-		return ""
+		return 0
 	}
 }
 
-// Respond to events and update ui text in these functions
-func addEventResponses(ui *tcellui.TcellUI, tmr *timer.Timer) {
-	key := tcellui.Trigger{State: int(timer.STOPPED), Char: 's'}
+// Responses to actions in each state
+func (self *session) addEventResponses() {
+	tmr := self.tmr
+	self.responses = map[timer.TimerState]map[string]func(){}
 	startFunc := func() {
 		tmr.Start()
 	}
@@ -150,58 +414,52 @@ func addEventResponses(ui *tcellui.TcellUI, tmr *timer.Timer) {
 	skipFunc := func() {
 		tmr.Skip()
 	}
-	ui.AddEventResponse(key, startFunc)
-	key = tcellui.Trigger{State: int(timer.PRE_WORK), Char: 's'}
-	ui.AddEventResponse(key, startFunc)
-	key = tcellui.Trigger{State: int(timer.PRE_WORK), Char: 'k'}
-	ui.AddEventResponse(key, skipFunc)
-	key = tcellui.Trigger{State: int(timer.WORK), Char: 'p'}
-	ui.AddEventResponse(key, pauseFunc)
-	key = tcellui.Trigger{State: int(timer.WORK), Char: 'k'}
-	ui.AddEventResponse(key, skipFunc)
-	key = tcellui.Trigger{State: int(timer.WORK_PAUSED), Char: 's'}
-	ui.AddEventResponse(key, startFunc)
-	key = tcellui.Trigger{State: int(timer.PRE_SBREAK), Char: 's'}
-	ui.AddEventResponse(key, startFunc)
-	key = tcellui.Trigger{State: int(timer.PRE_SBREAK), Char: 'k'}
-	ui.AddEventResponse(key, skipFunc)
-	key = tcellui.Trigger{State: int(timer.SBREAK), Char: 'p'}
-	ui.AddEventResponse(key, pauseFunc)
-	key = tcellui.Trigger{State: int(timer.SBREAK), Char: 'k'}
-	ui.AddEventResponse(key, skipFunc)
-	key = tcellui.Trigger{State: int(timer.SBREAK_PAUSED), Char: 's'}
-	ui.AddEventResponse(key, startFunc)
-	key = tcellui.Trigger{State: int(timer.PRE_LBREAK), Char: 's'}
-	ui.AddEventResponse(key, startFunc)
-	key = tcellui.Trigger{State: int(timer.PRE_LBREAK), Char: 'k'}
-	ui.AddEventResponse(key, skipFunc)
-	key = tcellui.Trigger{State: int(timer.LBREAK), Char: 'p'}
-	ui.AddEventResponse(key, pauseFunc)
-	key = tcellui.Trigger{State: int(timer.LBREAK), Char: 'k'}
-	ui.AddEventResponse(key, skipFunc)
-	key = tcellui.Trigger{State: int(timer.LBREAK_PAUSED), Char: 's'}
-	ui.AddEventResponse(key, startFunc)
-}
-
-func pomoDoroString(tmr *timer.Timer, wc, ec string) string {
-	pomodoros := ""
-	for i := 0; i < tmr.WorkIter(); i++ {
-		pomodoros += wc + " "
-	}
-	remaining := ""
-	for i := 0; i < tmr.MaxWorkIter()-tmr.WorkIter(); i++ {
-		pomodoros += ec + " "
-	}
-	return "Pomodoros: " + pomodoros + remaining + "\n"
-}
-
-func breakString(tmr *timer.Timer, bc string) string {
-	remaining := ""
-	for i := 0; i < tmr.RemainingBreaks(); i++ {
-		remaining += bc + " "
-	}
-	if remaining == "" {
-		return ""
-	}
-	return "Breaks Left: " + remaining + "\n"
+	resetFunc := self.confirmed("Reset the current phase?", func() {
+		tmr.ResetPhase()
+	})
+	restartFunc := self.confirmed("Restart the session?", self.restart)
+	endFunc := self.confirmed("End the session early?", func() {
+		tmr.Stop()
+	})
+	voidFunc := self.confirmed("Void this pomodoro?", self.void)
+	bind := func(state timer.TimerState, action string, response func()) {
+		if self.responses[state] == nil {
+			self.responses[state] = map[string]func(){}
+		}
+		self.responses[state][action] = response
+	}
+	bind(timer.STOPPED, ACTION_START, startFunc)
+	bind(timer.PRE_WORK, ACTION_START, startFunc)
+	bind(timer.PRE_WORK, ACTION_SKIP, skipFunc)
+	bind(timer.WORK, ACTION_PAUSE, pauseFunc)
+	bind(timer.WORK, ACTION_SKIP, skipFunc)
+	bind(timer.WORK_PAUSED, ACTION_START, startFunc)
+	bind(timer.WORK, ACTION_VOID, voidFunc)
+	bind(timer.WORK_PAUSED, ACTION_VOID, voidFunc)
+	bind(timer.PRE_SBREAK, ACTION_START, startFunc)
+	bind(timer.PRE_SBREAK, ACTION_SKIP, skipFunc)
+	bind(timer.SBREAK, ACTION_PAUSE, pauseFunc)
+	bind(timer.SBREAK, ACTION_SKIP, skipFunc)
+	bind(timer.SBREAK_PAUSED, ACTION_START, startFunc)
+	bind(timer.PRE_LBREAK, ACTION_START, startFunc)
+	bind(timer.PRE_LBREAK, ACTION_SKIP, skipFunc)
+	bind(timer.LBREAK, ACTION_PAUSE, pauseFunc)
+	bind(timer.LBREAK, ACTION_SKIP, skipFunc)
+	bind(timer.LBREAK_PAUSED, ACTION_START, startFunc)
+	bind(timer.SCHEDULED, ACTION_START, self.startScheduled)
+	for _, state := range []timer.TimerState{
+		timer.WORK, timer.WORK_PAUSED,
+		timer.SBREAK, timer.SBREAK_PAUSED,
+		timer.LBREAK, timer.LBREAK_PAUSED,
+	} {
+		bind(state, ACTION_RESET, resetFunc)
+	}
+	for state := timer.STOPPED; state <= timer.SCHEDULED; state++ {
+		if state != timer.STOPPED {
+			bind(state, ACTION_RESTART, restartFunc)
+		}
+		if state != timer.DONE {
+			bind(state, ACTION_END, endFunc)
+		}
+	}
 }
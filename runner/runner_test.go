@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestSession() (*session, *view.Headless) {
+	cfg := &Config{
+		WorkChar:    DEFAULT_WORK_CHAR,
+		BreakChar:   DEFAULT_BREAK_CHAR,
+		EmptyChar:   DEFAULT_EMPTY_CHAR,
+		KeyBindings: DefaultKeyBindings(),
+		Task:        "Write tests",
+	}
+	tmr := timer.NewTimer(60, 30, 90, 3, 2, false)
+	headless := view.NewHeadless()
+	return newSession(cfg, tmr, headless, headless), headless
+}
+
+func TestSessionActions(t *testing.T) {
+	s, headless := newTestSession()
+	s.handle(view.Input{Action: ACTION_START})
+	s.render()
+	model := headless.Last()
+	if model.State != timer.WORK {
+		t.Error("Expected state to be WORK. Got:", model.State)
+	}
+	if model.Remaining != 60 || model.Task != "Write tests" {
+		t.Error("Expected 60 seconds of 'Write tests'. Got:", model.Remaining, model.Task)
+	}
+	// Not bound in WORK
+	s.handle(view.Input{Action: ACTION_START})
+	s.handle(view.Input{Action: ACTION_PAUSE})
+	s.render()
+	if headless.Last().State != timer.WORK_PAUSED {
+		t.Error("Expected state to be WORK_PAUSED. Got:", headless.Last().State)
+	}
+}
+
+func TestSessionConfirmation(t *testing.T) {
+	s, headless := newTestSession()
+	s.handle(view.Input{Action: ACTION_START})
+	s.handle(view.Input{Action: ACTION_END})
+	s.render()
+	if headless.Last().Overlay == "" {
+		t.Error("Expected a confirmation prompt")
+	}
+	s.handle(view.Input{Key: keys.RuneKey('n')})
+	s.render()
+	if headless.Last().Overlay != "" || headless.Last().State != timer.WORK {
+		t.Error("Expected the prompt to be cancelled. Got:", headless.Last().State)
+	}
+	s.handle(view.Input{Action: ACTION_END})
+	s.handle(view.Input{Key: keys.RuneKey('y')})
+	s.render()
+	if headless.Last().State != timer.DONE || !headless.Last().Aborted {
+		t.Error("Expected the session to be ended early. Got:", headless.Last().State)
+	}
+}
+
+func TestSessionQuit(t *testing.T) {
+	s, headless := newTestSession()
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s.start(wg)
+	headless.Send(view.Input{Action: ACTION_QUIT})
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Error("Expected the session to quit")
+	}
+	if headless.Renders() == 0 {
+		t.Error("Expected the session to be rendered")
+	}
+}
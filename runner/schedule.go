@@ -0,0 +1,192 @@
+package runner
+
+import (
+	"errors"
+	"pomodoro/history"
+	"pomodoro/timer"
+	"strings"
+	"time"
+)
+
+// Layout of clock times in the config and the --at flag
+const CLOCK_LAYOUT = "15:04"
+
+// A part of each day, e.g. 09:00-17:30, in minutes after midnight
+type clockRange struct {
+	start int
+	end   int
+}
+
+// Minutes after midnight of a time like "09:00"
+func parseClock(text string) (int, error) {
+	t, err := time.Parse(CLOCK_LAYOUT, strings.TrimSpace(text))
+	if err != nil {
+		return 0, errors.New("expected a time like \"09:00\"")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// A range like "09:00-17:30". The end must be after the start
+func parseClockRange(text string) (clockRange, error) {
+	first, second, ok := strings.Cut(text, "-")
+	if !ok {
+		return clockRange{}, errors.New("expected times like \"09:00-17:30\"")
+	}
+	start, err := parseClock(first)
+	if err != nil {
+		return clockRange{}, err
+	}
+	end, err := parseClock(second)
+	if err != nil {
+		return clockRange{}, err
+	}
+	if end <= start {
+		return clockRange{}, errors.New("the end must be after the start")
+	}
+	return clockRange{start, end}, nil
+}
+
+// Start and end of the range on the day of t
+func (self clockRange) on(t time.Time) (start, end time.Time) {
+	day := history.StartOfDay(t)
+	return day.Add(time.Duration(self.start) * time.Minute), day.Add(time.Duration(self.end) * time.Minute)
+}
+
+// Parsed working_hours and lunch. ok is false when the field isn't set
+func (self *Config) workingHours() (hours clockRange, ok bool) {
+	hours, err := parseClockRange(self.WorkingHours)
+	return hours, err == nil
+}
+
+func (self *Config) lunch() (lunch clockRange, ok bool) {
+	lunch, err := parseClockRange(self.Lunch)
+	return lunch, err == nil
+}
+
+// Start the first pomodoro at a time like "09:00", tomorrow if it has already
+// passed today
+func (self *Config) ScheduleStart(text string, now time.Time) error {
+	minutes, err := parseClock(text)
+	if err != nil {
+		return errors.New("--at: " + err.Error() + ", got \"" + text + "\"")
+	}
+	at := history.StartOfDay(now).Add(time.Duration(minutes) * time.Minute)
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	self.StartAt = at
+	return nil
+}
+
+// Work out when the session may start and when it ends from --at and the
+// working hours. Must be called with the lock held
+func (self *session) planDay(now time.Time) {
+	start, reason, end := self.cfg.firstStart(now)
+	self.workdayEnd = end
+	if !start.IsZero() {
+		self.scheduleStart(start, reason, now)
+	}
+}
+
+// When the first pomodoro of a session started at now can start, now if it
+// can start straight away
+func (self *Config) PlannedStart(now time.Time) time.Time {
+	start, _, _ := self.firstStart(now)
+	if start.IsZero() {
+		return now
+	}
+	return start
+}
+
+// The later of --at and the start of working hours, zero when work can start
+// straight away. The working hours are those of the day the session starts,
+// and end is zero for a session set to start after them
+func (self *Config) firstStart(now time.Time) (start time.Time, reason string, end time.Time) {
+	day := now
+	if self.StartAt.After(now) {
+		day = self.StartAt
+	}
+	if hours, ok := self.workingHours(); ok {
+		open, close := hours.on(day)
+		if day.Before(close) {
+			end = close
+		}
+		if day.Before(open) {
+			start, reason = open, "Working hours start at "+open.Format(CLOCK_LAYOUT)
+		}
+	}
+	if self.StartAt.After(now) && !self.StartAt.Before(start) {
+		start, reason = self.StartAt, "Starts at "+self.StartAt.Format(CLOCK_LAYOUT)
+	}
+	return
+}
+
+// Wait until at before starting work. Must be called with the lock held
+func (self *session) scheduleStart(at time.Time, reason string, now time.Time) {
+	self.scheduledFor = at
+	self.scheduleReason = reason
+	self.tmr.Schedule(int(at.Sub(now).Round(time.Second) / time.Second))
+}
+
+// Start work now instead of waiting. A lunch break that was cut short isn't
+// scheduled again. Must be called with the lock held
+func (self *session) startScheduled() {
+	self.skippedUntil = self.scheduledFor
+	self.tmr.Start()
+}
+
+// End the session once working hours are over and hold work back during
+// lunch. Only a phase that hasn't started yet, or that just started by
+// itself, is held back or cut. Must be called with the lock held before the
+// phase is recorded
+func (self *session) followSchedule(now time.Time) {
+	state := self.tmr.TimerState()
+	phase := phaseOf(state)
+	autoStarted := phase != "" && phase != self.current.phase && self.tmr.Counter() == 0
+	waiting := autoStarted || state == timer.PRE_WORK || state == timer.PRE_SBREAK ||
+		state == timer.PRE_LBREAK || state == timer.SCHEDULED
+	if !waiting {
+		return
+	}
+	if !self.workdayEnd.IsZero() && !now.Before(self.workdayEnd) {
+		self.notify("Working hours ended at " + self.workdayEnd.Format(CLOCK_LAYOUT))
+		self.workdayEnd = time.Time{}
+		self.tmr.Stop()
+		return
+	}
+	lunch, ok := self.cfg.lunch()
+	if !ok || !(state == timer.PRE_WORK || autoStarted && state == timer.WORK) {
+		return
+	}
+	start, end := lunch.on(now)
+	if now.Before(start) || !now.Before(end) || now.Before(self.skippedUntil) {
+		return
+	}
+	if state == timer.WORK {
+		self.tmr.ResetPhase()
+	}
+	self.scheduleStart(end, "Lunch until "+end.Format(CLOCK_LAYOUT), now)
+}
+
+// e.g. "Lunch until 13:30". "" unless waiting for a scheduled start
+func (self *session) scheduleText() string {
+	if self.tmr.TimerState() != timer.SCHEDULED {
+		return ""
+	}
+	return self.scheduleReason
+}
+
+// Why a working_hours or lunch value is invalid, or "" if it is valid
+func clockRangeProblem(value interface{}) string {
+	text, ok := value.(string)
+	if !ok {
+		return "expected a string"
+	}
+	if text == "" {
+		return ""
+	}
+	if _, err := parseClockRange(text); err != nil {
+		return err.Error()
+	}
+	return ""
+}
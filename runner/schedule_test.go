@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"pomodoro/timer"
+	"testing"
+	"time"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 10, 19, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseClockRange(t *testing.T) {
+	hours, err := parseClockRange("09:00-17:30")
+	if err != nil || hours.start != 540 || hours.end != 1050 {
+		t.Error("Expected 540 to 1050 minutes. Got:", hours, err)
+	}
+	for _, text := range []string{"9-5", "17:30-09:00", "09:00", "25:00-26:00"} {
+		if _, err := parseClockRange(text); err == nil {
+			t.Error("Expected an error for", text)
+		}
+	}
+}
+
+func TestScheduleStart(t *testing.T) {
+	cfg := defaultConfig("config.json")
+	cfg.ScheduleStart("09:30", at(8, 0))
+	if !cfg.StartAt.Equal(at(9, 30)) {
+		t.Error("Expected 09:30 today. Got:", cfg.StartAt)
+	}
+	cfg.ScheduleStart("07:00", at(8, 0))
+	if !cfg.StartAt.Equal(at(7, 0).AddDate(0, 0, 1)) {
+		t.Error("Expected 07:00 tomorrow. Got:", cfg.StartAt)
+	}
+	if err := cfg.ScheduleStart("soon", at(8, 0)); err == nil {
+		t.Error("Expected an invalid time to fail")
+	}
+}
+
+func TestWorkingHours(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.WorkingHours = "09:00-17:30"
+	s.planDay(at(8, 59))
+	if s.tmr.TimerState() != timer.SCHEDULED || s.tmr.ScheduleLeft() != 60 {
+		t.Fatal("Expected to wait a minute. Got:", s.tmr.TimerState(), s.tmr.ScheduleLeft())
+	}
+	if text := s.model().TimerText(); text != "Working hours start at 09:00 (01m:00s left)" {
+		t.Error("Expected the wait to be shown. Got:", text)
+	}
+	s.act(ACTION_START)
+	s.tickAndRecord(4)
+	s.followSchedule(at(17, 30))
+	if s.tmr.TimerState() != timer.DONE || s.currentMessage() != "Working hours ended at 17:30" {
+		t.Error("Expected the session to end. Got:", s.tmr.TimerState(), s.currentMessage())
+	}
+}
+
+func TestWorkingHoursLetPhaseFinish(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.WorkingHours = "09:00-17:30"
+	s.planDay(at(17, 0))
+	s.act(ACTION_START)
+	s.tickAndRecord(1)
+	s.followSchedule(at(17, 31))
+	if s.tmr.TimerState() != timer.WORK {
+		t.Error("Expected the pomodoro in progress to finish. Got:", s.tmr.TimerState())
+	}
+}
+
+func TestWorkingHoursOnStartDay(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.WorkingHours = "09:00-17:30"
+	s.cfg.ScheduleStart("09:00", at(18, 0))
+	s.planDay(at(18, 0))
+	if !s.workdayEnd.Equal(at(17, 30).AddDate(0, 0, 1)) {
+		t.Error("Expected the session to end at 17:30 tomorrow. Got:", s.workdayEnd)
+	}
+	s.cfg.ScheduleStart("18:30", at(18, 0))
+	s.planDay(at(18, 0))
+	if !s.workdayEnd.IsZero() {
+		t.Error("Expected a start after working hours not to end with them. Got:", s.workdayEnd)
+	}
+}
+
+func TestStartAtBeforeWorkingHours(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.WorkingHours = "09:00-17:30"
+	s.cfg.ScheduleStart("08:00", at(7, 0))
+	if start := s.cfg.PlannedStart(at(7, 0)); !start.Equal(at(9, 0)) {
+		t.Error("Expected the plan to start with working hours at 09:00. Got:", start)
+	}
+	s.planDay(at(7, 0))
+	if !s.scheduledFor.Equal(at(9, 0)) || s.scheduleReason != "Working hours start at 09:00" {
+		t.Error("Expected to wait for working hours. Got:", s.scheduledFor, s.scheduleReason)
+	}
+	s.cfg.ScheduleStart("10:00", at(7, 0))
+	if start := s.cfg.PlannedStart(at(7, 0)); !start.Equal(at(10, 0)) {
+		t.Error("Expected the plan to start at 10:00. Got:", start)
+	}
+}
+
+func TestLunch(t *testing.T) {
+	s := newRecordSession(t)
+	s.cfg.Lunch = "12:30-13:15"
+	s.tmr.AutoAdvance = true
+	s.act(ACTION_START)
+	s.tickAndRecord(4)
+	s.tmr.Tick()
+	s.tmr.Tick()
+	s.tmr.Tick()
+	s.followSchedule(at(12, 45))
+	s.record()
+	if s.tmr.TimerState() != timer.SCHEDULED || s.tmr.ScheduleLeft() != 30*60 {
+		t.Fatal("Expected work to wait for the end of lunch. Got:", s.tmr.TimerState(), s.tmr.ScheduleLeft())
+	}
+	if s.model().Schedule != "Lunch until 13:15" {
+		t.Error("Expected the lunch to be shown. Got:", s.model().Schedule)
+	}
+	s.act(ACTION_START)
+	s.tmr.AutoAdvance = false
+	s.tickAndRecord(4)
+	s.act(ACTION_SKIP)
+	s.followSchedule(at(12, 50))
+	if s.tmr.TimerState() != timer.PRE_WORK {
+		t.Error("Expected lunch not to be scheduled again once skipped")
+	}
+}
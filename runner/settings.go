@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	FIELD_WORK_TIME = iota
+	FIELD_BREAK_TIME
+	FIELD_LONG_BREAK_TIME
+	FIELD_LONG_BREAK_INTERVAL
+	FIELD_TOTAL_POMODOROS
+	FIELD_AUTO_START
+)
+
+type settingsField struct {
+	// Config key the field is saved to, e.g. "work_time"
+	key        string
+	label      string
+	value      string
+	isBool     bool
+	isDuration bool
+	// Value when the form was opened
+	initial string
+}
+
+// In-app editor for the timer settings. Values are edited as text and only
+// validated when applied
+type settingsForm struct {
+	fields   []settingsField
+	selected int
+	message  string
+}
+
+func newSettingsForm(tmr *timer.Timer) *settingsForm {
+	duration := func(seconds int) string {
+		return Duration(seconds).String()
+	}
+	autoStart := "no"
+	if tmr.AutoAdvance {
+		autoStart = "yes"
+	}
+	form := &settingsForm{
+		fields: []settingsField{
+			FIELD_WORK_TIME:           {key: "work_time", label: "Work time", value: duration(tmr.MaxWorkCounter()), isDuration: true},
+			FIELD_BREAK_TIME:          {key: "break_time", label: "Break time", value: duration(tmr.MaxSbreakCounter()), isDuration: true},
+			FIELD_LONG_BREAK_TIME:     {key: "long_break_time", label: "Long break time", value: duration(tmr.MaxLbreakCounter()), isDuration: true},
+			FIELD_LONG_BREAK_INTERVAL: {key: "long_break_interval", label: "Long break interval", value: strconv.Itoa(tmr.WorkChunk())},
+			FIELD_TOTAL_POMODOROS:     {key: "total_pomodoros", label: "Total pomodoros", value: strconv.Itoa(tmr.MaxWorkIter())},
+			FIELD_AUTO_START:          {key: "auto_start", label: "Auto start", value: autoStart, isBool: true},
+		},
+	}
+	for i := range form.fields {
+		form.fields[i].initial = form.fields[i].value
+	}
+	return form
+}
+
+// Fields whose values were changed in the form, keyed by config key. Values
+// are in the form config set takes, e.g. "50m" or "true"
+func (self *settingsForm) changed() map[string]string {
+	values := map[string]string{}
+	for _, f := range self.fields {
+		if f.value == f.initial {
+			continue
+		}
+		value := f.value
+		if f.isBool {
+			value = strconv.FormatBool(f.value == "yes")
+		}
+		values[f.key] = value
+	}
+	return values
+}
+
+func (self *settingsForm) Text() string {
+	width := 0
+	for _, f := range self.fields {
+		width = maxInt(width, len(f.label))
+	}
+	text := "Settings\n\n"
+	for i, f := range self.fields {
+		cursor := "  "
+		value := f.value
+		if i == self.selected {
+			cursor = "> "
+			if !f.isBool {
+				value += "_"
+			}
+		}
+		text += fmt.Sprintf("%s%-*s  %s\n", cursor, width, f.label, value)
+	}
+	text += "\nUp/Down: Select  Space: Toggle  Enter: Apply\n"
+	text += "Ctrl+S: Apply and save  Esc: Cancel\n"
+	if self.message != "" {
+		text += "\n" + self.message
+	}
+	return text
+}
+
+func (self *settingsForm) edit(key keys.Key) {
+	field := &self.fields[self.selected]
+	switch {
+	case key.Code == tcell.KeyUp || key.Code == tcell.KeyBacktab:
+		self.selected = (self.selected + len(self.fields) - 1) % len(self.fields)
+	case key.Code == tcell.KeyDown || key.Code == tcell.KeyTab:
+		self.selected = (self.selected + 1) % len(self.fields)
+	case field.isBool && key == keys.RuneKey(' '):
+		if field.value == "yes" {
+			field.value = "no"
+		} else {
+			field.value = "yes"
+		}
+	case field.isBool:
+		return
+	case key.Code == tcell.KeyBackspace || key.Code == tcell.KeyBackspace2:
+		if len(field.value) > 0 {
+			field.value = field.value[:len(field.value)-1]
+		}
+	case key.Code == tcell.KeyRune && key.Char >= '0' && key.Char <= '9':
+		field.value += string(key.Char)
+	case field.isDuration && key.Code == tcell.KeyRune && strings.ContainsRune("hms.", key.Char):
+		field.value += string(key.Char)
+	}
+}
+
+func (self *settingsForm) positiveInt(field int) (int, error) {
+	f := self.fields[field]
+	n, err := strconv.Atoi(f.value)
+	if err != nil || n < 1 {
+		return 0, errors.New(f.label + " must be a whole number above 0")
+	}
+	return n, nil
+}
+
+func (self *settingsForm) duration(field int) (int, error) {
+	f := self.fields[field]
+	d, err := ParseDuration(f.value)
+	if err != nil {
+		return 0, errors.New(f.label + " must be a duration like 25m, 90s or 1h15m")
+	}
+	return int(d), nil
+}
+
+// Validate the form and write the values to the config and the timer
+func (self *settingsForm) apply(cfg *Config, tmr *timer.Timer) error {
+	values := make([]int, FIELD_AUTO_START)
+	errMsgs := []string{}
+	for field := range values {
+		parse := self.positiveInt
+		if self.fields[field].isDuration {
+			parse = self.duration
+		}
+		n, err := parse(field)
+		if err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+		values[field] = n
+	}
+	total := values[FIELD_TOTAL_POMODOROS]
+	if total > 0 && total <= tmr.WorkIter() {
+		errMsgs = append(errMsgs, fmt.Sprintf(
+			"Total pomodoros must be more than the %d already done",
+			tmr.WorkIter(),
+		))
+	}
+	if len(errMsgs) > 0 {
+		return errors.New(strings.Join(errMsgs, "\n"))
+	}
+	cfg.WorkTime = Duration(values[FIELD_WORK_TIME])
+	cfg.BreakTime = Duration(values[FIELD_BREAK_TIME])
+	cfg.LongBreakTime = Duration(values[FIELD_LONG_BREAK_TIME])
+	cfg.LongBreakInterval = values[FIELD_LONG_BREAK_INTERVAL]
+	cfg.TotalPomodoros = total
+	cfg.AutoStart = self.fields[FIELD_AUTO_START].value == "yes"
+	tmr.SetDurations(int(cfg.WorkTime), int(cfg.BreakTime), int(cfg.LongBreakTime))
+	tmr.SetWorkChunk(cfg.LongBreakInterval)
+	tmr.SetMaxWorkIter(cfg.TotalPomodoros)
+	tmr.AutoAdvance = cfg.AutoStart
+	return nil
+}
+
+func (self *session) handleSettingsKey(key keys.Key) {
+	form := self.settings
+	switch key.Code {
+	case tcell.KeyEscape:
+		self.closeOverlay()
+	case tcell.KeyEnter, tcell.KeyCtrlS:
+		err := form.apply(self.cfg, self.tmr)
+		if err != nil {
+			form.message = err.Error()
+			return
+		}
+		if key.Code == tcell.KeyCtrlS {
+			changed := form.changed()
+			err = self.cfg.SaveValues(changed)
+			if err != nil {
+				form.message = "Failed to save " + self.cfg.Path() + ": " + err.Error()
+				return
+			}
+			// Our own write isn't a change to reload
+			self.configStamp = statFile(self.cfg.Path())
+			for key := range changed {
+				copyField(&self.loaded, self.cfg, key)
+			}
+		}
+		self.closeOverlay()
+	default:
+		form.edit(key)
+	}
+}
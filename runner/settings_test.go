@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestSettingsFormApply(t *testing.T) {
+	cfg := &Config{}
+	tmr := timer.NewTimer(25*60, 5*60, 15*60, 8, 4, false)
+	form := newSettingsForm(tmr)
+	// "25m" to "50m"
+	form.edit(keys.Key{Code: tcell.KeyBackspace2})
+	form.edit(keys.Key{Code: tcell.KeyBackspace2})
+	form.edit(keys.Key{Code: tcell.KeyBackspace2})
+	form.edit(keys.RuneKey('5'))
+	form.edit(keys.RuneKey('0'))
+	form.edit(keys.RuneKey('m'))
+	form.fields[FIELD_BREAK_TIME].value = "90s"
+	form.selected = FIELD_AUTO_START
+	form.edit(keys.RuneKey(' '))
+	err := form.apply(cfg, tmr)
+	if err != nil {
+		t.Error("Expected settings to be valid. Got:", err)
+	}
+	if tmr.MaxWorkCounter() != 50*60 || tmr.MaxSbreakCounter() != 90 {
+		t.Error("Expected 3000 and 90 seconds. Got:", tmr.MaxWorkCounter(), tmr.MaxSbreakCounter())
+	}
+	if !tmr.AutoAdvance || !cfg.AutoStart {
+		t.Error("Expected auto start to be enabled")
+	}
+}
+
+func TestSettingsFormValidation(t *testing.T) {
+	cfg := &Config{}
+	tmr := timer.NewTimer(1, 1, 1, 3, 2, false)
+	tmr.Start()
+	tmr.Skip()
+	tmr.Skip()
+	form := newSettingsForm(tmr)
+	form.fields[FIELD_BREAK_TIME].value = ""
+	form.fields[FIELD_TOTAL_POMODOROS].value = "1"
+	err := form.apply(cfg, tmr)
+	if err == nil {
+		t.Error("Expected settings to be rejected")
+	}
+	if tmr.MaxWorkIter() != 3 {
+		t.Error("Expected total pomodoros to be unchanged. Got:", tmr.MaxWorkIter())
+	}
+}
+
+func TestSettingsFormSavesChangedFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("# Long days\nwork_time = \"50m\"\nbreak_char = \"B\"\n"), 0644)
+	cfg, _ := ReadConfig(path, "")
+	cfg.BreakChar = "Z"
+	cfg.TestMode()
+	tmr := timer.NewTimer(int(cfg.WorkTime), int(cfg.BreakTime), int(cfg.LongBreakTime), 5, 2, false)
+	form := newSettingsForm(tmr)
+	form.fields[FIELD_TOTAL_POMODOROS].value = "6"
+	form.fields[FIELD_AUTO_START].value = "yes"
+	err := form.apply(&cfg, tmr)
+	if err == nil {
+		err = cfg.SaveValues(form.changed())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	expected := "# Long days\nwork_time = \"50m\"\nbreak_char = \"B\"\nauto_start = true\ntotal_pomodoros = 6\n"
+	if string(data) != expected {
+		t.Error("Expected only the changed fields to be saved. Got:", string(data))
+	}
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"pomodoro/tcellui"
+	"pomodoro/tcellui/tcelltest"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata")
+
+// Drive a session through every state by pressing keys on a simulation screen
+// and compare each frame against testdata/session.golden
+func TestSessionSnapshots(t *testing.T) {
+	cfg := &Config{
+		WorkChar:    "X",
+		BreakChar:   "b",
+		EmptyChar:   "-",
+		KeyBindings: DefaultKeyBindings(),
+	}
+	screen := tcell.NewSimulationScreen("UTF-8")
+	ui, err := tcellui.NewTcellUIWithScreen(screen, cfg.KeyMap())
+	if err != nil {
+		t.Fatal("Expected simulation screen to initialize. Got:", err)
+	}
+	defer ui.Close()
+	screen.SetSize(40, 18)
+	screen.PostEvent(tcell.NewEventResize(40, 18))
+	tmr := timer.NewTimer(60, 30, 90, 3, 2, false)
+	s := newSession(cfg, tmr, ui, ui)
+	inputs := make(chan view.Input)
+	go ui.Listen(inputs)
+
+	press := func(r rune) {
+		screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		select {
+		case input := <-inputs:
+			s.handle(input)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected an input for '%c'", r)
+		}
+		s.render()
+	}
+	// Events are handled in order so the resize is done once this arrives
+	press('x')
+	frames := "== start (" + tmr.TimerState().String() + ")\n"
+	frames += tcelltest.ScreenText(screen)
+	visited := map[timer.TimerState]bool{}
+	// The first 's' starts the scheduled work straight away
+	s.scheduleStart(at(9, 0), "Starts at 09:00", at(8, 59))
+	s.render()
+	visited[tmr.TimerState()] = true
+	frames += "== scheduled (" + tmr.TimerState().String() + ")\n"
+	frames += tcelltest.ScreenText(screen)
+	for _, r := range "spskspsksksps?xksk" {
+		press(r)
+		visited[tmr.TimerState()] = true
+		frames += "== '" + string(r) + "' (" + tmr.TimerState().String() + ")\n"
+		frames += tcelltest.ScreenText(screen)
+	}
+	for state := timer.STOPPED; state <= timer.SCHEDULED; state++ {
+		if state != timer.STOPPED && !visited[state] {
+			t.Error("Expected to visit every state. Missed:", state)
+		}
+	}
+
+	path := filepath.Join("testdata", "session.golden")
+	if *update {
+		err := os.WriteFile(path, []byte(frames), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("Failed to read golden file. Run with -update to create it:", err)
+	}
+	if frames != string(want) {
+		t.Errorf("Frames don't match %s\nExpected:\n%s\nGot:\n%s", path, want, frames)
+	}
+}
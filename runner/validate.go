@@ -0,0 +1,510 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"pomodoro/keys"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// A problem with a value in a config file. Line and Column are 0 when the
+// position isn't known
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Field   string
+	Value   string
+	Message string
+	// Closest valid name for an unknown one
+	Suggestion string
+}
+
+// e.g. config.toml:3:13: work_time: must be at least 1, got 0
+func (self *ValidationError) Error() string {
+	text := self.File
+	if self.Line > 0 {
+		text += ":" + strconv.Itoa(self.Line) + ":" + strconv.Itoa(self.Column)
+	}
+	text += ": "
+	if self.Field != "" {
+		text += self.Field + ": "
+	}
+	text += self.Message
+	if self.Value != "" {
+		text += ", got " + self.Value
+	}
+	if self.Suggestion != "" {
+		text += ", did you mean \"" + self.Suggestion + "\"?"
+	}
+	return text
+}
+
+// What a config field holds
+const (
+	KIND_STRING = iota
+	KIND_BOOL
+	KIND_DURATION
+	KIND_COUNT
+	// Like KIND_DURATION and KIND_COUNT but 0 turns the setting off
+	KIND_OPTIONAL_DURATION
+	KIND_OPTIONAL_COUNT
+	// A whole number from 0 to 100
+	KIND_PERCENT
+	// A list of strings
+	KIND_LIST
+	// One of the values in fieldChoices
+	KIND_CHOICE
+	// Times of day like "09:00-17:30", or ""
+	KIND_CLOCK_RANGE
+	KIND_CHAR
+	KIND_KEY_BINDINGS
+	KIND_PROFILES
+)
+
+var fieldKinds = map[string]int{
+	"work_mp3":               KIND_STRING,
+	"break_mp3":              KIND_STRING,
+	"volume":                 KIND_PERCENT,
+	"work_time":              KIND_DURATION,
+	"break_time":             KIND_DURATION,
+	"long_break_time":        KIND_DURATION,
+	"long_break_interval":    KIND_COUNT,
+	"auto_start":             KIND_BOOL,
+	"total_pomodoros":        KIND_COUNT,
+	"count_skipped_work":     KIND_BOOL,
+	"pomodoro_char":          KIND_CHAR,
+	"break_char":             KIND_CHAR,
+	"empty_char":             KIND_CHAR,
+	"history_file":           KIND_STRING,
+	"daily_goal":             KIND_OPTIONAL_COUNT,
+	"weekly_goal":            KIND_OPTIONAL_COUNT,
+	"daily_focus_goal":       KIND_OPTIONAL_DURATION,
+	"weekly_focus_goal":      KIND_OPTIONAL_DURATION,
+	"goal_command":           KIND_STRING,
+	"short_break_activities": KIND_LIST,
+	"long_break_activities":  KIND_LIST,
+	"activities_file":        KIND_STRING,
+	"activity_order":         KIND_CHOICE,
+	"idle_timeout":           KIND_OPTIONAL_DURATION,
+	"idle_command":           KIND_STRING,
+	"working_hours":          KIND_CLOCK_RANGE,
+	"lunch":                  KIND_CLOCK_RANGE,
+	"key_bindings":           KIND_KEY_BINDINGS,
+	"default_profile":        KIND_STRING,
+	"profiles":               KIND_PROFILES,
+}
+
+// Values a KIND_CHOICE field can take
+var fieldChoices = map[string][]string{
+	"activity_order": ACTIVITY_ORDERS,
+}
+
+// Checks the values read from a config file and removes the invalid ones so
+// they keep their defaults
+type validator struct {
+	file      string
+	positions map[string]position
+	// Names to report fields by, e.g. the environment variable that set it
+	names map[string]string
+	errs  []error
+}
+
+type position struct {
+	line   int
+	column int
+}
+
+func (self *validator) fail(field string, value interface{}, message string) {
+	self.suggest(field, value, message, "")
+}
+
+func (self *validator) suggest(field string, value interface{}, message, suggestion string) {
+	err := &ValidationError{
+		File: self.file, Field: field, Message: message, Suggestion: suggestion,
+	}
+	if value != nil {
+		data, _ := json.Marshal(value)
+		err.Value = string(data)
+	}
+	if name, ok := self.names[field]; ok {
+		err.Field = name
+	}
+	if pos, ok := self.positions[field]; ok {
+		err.Line = pos.line
+		err.Column = pos.column
+	}
+	self.errs = append(self.errs, err)
+}
+
+// Check every field of a config or a profile
+func (self *validator) fields(values map[string]interface{}, prefix string, profile bool) {
+	for _, key := range sortedKeys(values) {
+		field := joinKey(prefix, key)
+		kind, ok := fieldKinds[key]
+		if profile && (kind == KIND_PROFILES || key == "default_profile") {
+			ok = false
+		}
+		if !ok {
+			self.suggest(field, nil, "unknown field", closest(key, fieldKeys()))
+			delete(values, key)
+			continue
+		}
+		if !self.value(values[key], field, kind) {
+			delete(values, key)
+		}
+	}
+}
+
+// Returns false if the value is invalid
+func (self *validator) value(value interface{}, field string, kind int) bool {
+	switch kind {
+	case KIND_STRING:
+		if _, ok := value.(string); !ok {
+			self.fail(field, value, "expected a string")
+			return false
+		}
+	case KIND_BOOL:
+		if _, ok := value.(bool); !ok {
+			self.fail(field, value, "expected true or false")
+			return false
+		}
+	case KIND_DURATION, KIND_OPTIONAL_DURATION:
+		text := ""
+		switch value := value.(type) {
+		case string:
+			text = value
+		case json.Number:
+			text = value.String()
+		case int64, int, float64:
+			data, _ := json.Marshal(value)
+			text = string(data)
+		default:
+			self.fail(field, value, "expected a duration like \"25m\", \"90s\" or \"1h15m\"")
+			return false
+		}
+		parse := ParseDuration
+		if kind == KIND_OPTIONAL_DURATION {
+			parse = ParseOptionalDuration
+		}
+		if _, err := parse(text); err != nil {
+			self.fail(field, value, durationProblem(text))
+			return false
+		}
+	case KIND_COUNT, KIND_OPTIONAL_COUNT:
+		number, ok := wholeNumber(value)
+		if !ok {
+			self.fail(field, value, "expected a whole number")
+			return false
+		}
+		if number < 1 && kind == KIND_COUNT {
+			self.fail(field, value, "must be at least 1")
+			return false
+		}
+		if number < 0 {
+			self.fail(field, value, "must be at least 0")
+			return false
+		}
+	case KIND_PERCENT:
+		number, ok := wholeNumber(value)
+		if !ok {
+			self.fail(field, value, "expected a whole number")
+			return false
+		}
+		if number < 0 || number > 100 {
+			self.fail(field, value, "must be from 0 to 100")
+			return false
+		}
+	case KIND_LIST:
+		items, ok := value.([]interface{})
+		if !ok {
+			self.fail(field, value, "expected a list of strings")
+			return false
+		}
+		for _, item := range items {
+			if _, ok := item.(string); !ok {
+				self.fail(field, value, "expected a list of strings")
+				return false
+			}
+		}
+	case KIND_CHOICE:
+		choices := fieldChoices[field[strings.LastIndex(field, ".")+1:]]
+		str, _ := value.(string)
+		for _, choice := range choices {
+			if str == choice {
+				return true
+			}
+		}
+		self.fail(field, value, "expected one of "+strings.Join(choices, ", "))
+		return false
+	case KIND_CLOCK_RANGE:
+		if problem := clockRangeProblem(value); problem != "" {
+			self.fail(field, value, problem)
+			return false
+		}
+	case KIND_CHAR:
+		str, ok := value.(string)
+		if !ok {
+			self.fail(field, value, "expected a string")
+			return false
+		}
+		if r, _ := utf8.DecodeRuneInString(str); r == utf8.RuneError {
+			self.fail(field, value, "expected a character")
+			return false
+		}
+	case KIND_KEY_BINDINGS:
+		return self.keyBindings(value, field)
+	case KIND_PROFILES:
+		profiles, ok := value.(map[string]interface{})
+		if !ok {
+			self.fail(field, value, "expected a table of profiles")
+			return false
+		}
+		for _, name := range sortedKeys(profiles) {
+			profile, ok := profiles[name].(map[string]interface{})
+			if !ok {
+				self.fail(joinKey(field, name), profiles[name], "expected a table of values")
+				delete(profiles, name)
+				continue
+			}
+			self.fields(profile, joinKey(field, name), true)
+		}
+	}
+	return true
+}
+
+// Why ParseDuration rejected text, worded to fit a validation error
+func durationProblem(text string) string {
+	d, err := time.ParseDuration(text)
+	if minutes, e := strconv.ParseFloat(text, 64); e == nil {
+		d, err = time.Duration(minutes*float64(time.Minute)), nil
+	}
+	switch {
+	case err != nil:
+		return "expected a duration like \"25m\", \"90s\" or \"1h15m\""
+	case d < time.Second:
+		return "must be at least 1s"
+	default:
+		return "must be a whole number of seconds"
+	}
+}
+
+// Unknown actions and invalid keys are removed. Conflicts are left for
+// KeyBindings.Parse to report
+func (self *validator) keyBindings(value interface{}, field string) bool {
+	bindings, ok := value.(map[string]interface{})
+	if !ok {
+		self.fail(field, value, "expected a table of actions")
+		return false
+	}
+	for _, action := range sortedKeys(bindings) {
+		actionField := joinKey(field, action)
+		if !isAction(action) {
+			self.suggest(actionField, nil, "unknown action", closest(action, ACTIONS))
+			delete(bindings, action)
+			continue
+		}
+		names, ok := bindings[action].([]interface{})
+		if !ok || len(names) == 0 {
+			self.fail(actionField, bindings[action], "expected a list of keys")
+			delete(bindings, action)
+			continue
+		}
+		for _, name := range names {
+			str, ok := name.(string)
+			if !ok {
+				self.fail(actionField, name, "expected a key name")
+				delete(bindings, action)
+				break
+			}
+			if _, err := keys.ParseKey(str); err != nil {
+				self.fail(actionField, name, "invalid key")
+				delete(bindings, action)
+				break
+			}
+		}
+	}
+	return true
+}
+
+// The profile named by default_profile has to exist
+func (self *validator) defaultProfile(values map[string]interface{}) {
+	name, ok := values["default_profile"].(string)
+	if !ok || name == "" {
+		return
+	}
+	profiles, _ := values["profiles"].(map[string]interface{})
+	if _, ok := profiles[name]; !ok {
+		names := sortedKeys(profiles)
+		self.suggest("default_profile", name, "no such profile", closest(name, names))
+		delete(values, "default_profile")
+	}
+}
+
+// Parse and validate a config file. The returned JSON only holds valid values
+func validateConfig(path string, data []byte) ([]byte, []error) {
+	v := validator{file: path}
+	values, err := parseConfig(path, data)
+	if err != nil {
+		return nil, []error{err}
+	}
+	v.positions = keyPositions(path, data)
+	v.fields(values, "", false)
+	v.defaultProfile(values)
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		a, b := v.errs[i].(*ValidationError), v.errs[j].(*ValidationError)
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	valid, err := json.Marshal(values)
+	if err != nil {
+		return nil, append(v.errs, err)
+	}
+	return valid, v.errs
+}
+
+var (
+	yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+	tomlLine = regexp.MustCompile(`^toml: line \d+( \(last key "[^"]*"\))?: `)
+)
+
+// Decode a config file into plain maps, slices and values
+func parseConfig(path string, data []byte) (map[string]interface{}, error) {
+	var values interface{}
+	switch configFormat(path) {
+	case FORMAT_TOML:
+		_, err := toml.Decode(string(data), &values)
+		if err != nil {
+			var parseErr toml.ParseError
+			if errors.As(err, &parseErr) {
+				pos := offsetPosition(data, parseErr.Position.Start)
+				return nil, &ValidationError{
+					File: path, Line: pos.line, Column: pos.column,
+					Message: tomlLine.ReplaceAllString(parseErr.Error(), ""),
+				}
+			}
+			return nil, &ValidationError{File: path, Message: err.Error()}
+		}
+	case FORMAT_YAML:
+		err := yaml.Unmarshal(data, &values)
+		if err != nil {
+			verr := &ValidationError{File: path, Message: err.Error()}
+			if match := yamlLine.FindStringSubmatch(err.Error()); match != nil {
+				verr.Line, _ = strconv.Atoi(match[1])
+				verr.Column = 1
+				verr.Message = strings.TrimPrefix(err.Error(), match[0])
+			}
+			return nil, verr
+		}
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err := decoder.Decode(&values)
+		if err != nil {
+			verr := &ValidationError{File: path, Message: err.Error()}
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				pos := offsetPosition(data, int(syntaxErr.Offset))
+				verr.Line, verr.Column = pos.line, pos.column
+			}
+			return nil, verr
+		}
+	}
+	if values == nil {
+		// An empty YAML file
+		return map[string]interface{}{}, nil
+	}
+	table, ok := values.(map[string]interface{})
+	if !ok {
+		return nil, &ValidationError{
+			File: path, Line: 1, Column: 1, Message: "expected a table of values",
+		}
+	}
+	return table, nil
+}
+
+func wholeNumber(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		number, err := value.Int64()
+		return number, err == nil
+	case int64:
+		return value, true
+	case int:
+		return int64(value), true
+	case float64:
+		return int64(value), value == float64(int64(value))
+	default:
+		return 0, false
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// The closest option if it is only a couple of edits away, otherwise ""
+func closest(word string, options []string) string {
+	best := ""
+	bestDistance := 3
+	for _, option := range options {
+		distance := editDistance(strings.ToLower(word), strings.ToLower(option))
+		if distance < bestDistance {
+			best = option
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// Levenshtein distance
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,190 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func checkErrors(t *testing.T, name, data string, expected []string) Config {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, DEFAULT_SOUND_PATH), []byte{}, 0644)
+	path := filepath.Join(dir, name)
+	os.WriteFile(path, []byte(data), 0644)
+	cfg, errs := NewConfig(path, "")
+	if len(errs) != len(expected) {
+		t.Error("Expected", len(expected), "errors in", name, "Got:", errs)
+		return cfg
+	}
+	for i, err := range errs {
+		if want := path + expected[i]; err.Error() != want {
+			t.Error("Expected:", want, "Got:", err.Error())
+		}
+	}
+	return cfg
+}
+
+func TestValidateJSON(t *testing.T) {
+	cfg := checkErrors(t, "config.json", `{
+  "wrok_time": 50,
+  "break_time": 0,
+  "long_break_time": "1h15",
+  "auto_start": "yes",
+  "key_bindings": {"strat": ["s"], "pause": ["Ctrl+Nope"]}
+}`, []string{
+		":2:16: wrok_time: unknown field, did you mean \"work_time\"?",
+		":3:17: break_time: must be at least 1s, got 0",
+		":4:22: long_break_time: expected a duration like \"25m\", \"90s\" or \"1h15m\", got \"1h15\"",
+		":5:17: auto_start: expected true or false, got \"yes\"",
+		":6:29: key_bindings.strat: unknown action, did you mean \"start\"?",
+		":6:45: key_bindings.pause: invalid key, got \"Ctrl+Nope\"",
+	})
+	if cfg.WorkTime != DEFAULT_WORK_TIME || cfg.BreakTime != DEFAULT_BREAK_TIME ||
+		cfg.LongBreakTime != DEFAULT_LONG_BREAK_TIME || cfg.AutoStart {
+		t.Error("Expected invalid values to keep their defaults. Got:",
+			cfg.WorkTime, cfg.BreakTime, cfg.LongBreakTime, cfg.AutoStart)
+	}
+	if cfg.KeyBindings[ACTION_PAUSE][0] != "p" {
+		t.Error("Expected the invalid pause key to keep its default. Got:", cfg.KeyBindings[ACTION_PAUSE])
+	}
+}
+
+func TestValidateTOML(t *testing.T) {
+	checkErrors(t, "config.toml", `total_pomodoros = "8"
+empty_char = ""
+default_profile = "clasic"
+
+[profiles.classic]
+work_time = 25
+profiles = 1
+`, []string{
+		":1:19: total_pomodoros: expected a whole number, got \"8\"",
+		":2:14: empty_char: expected a character, got \"\"",
+		":3:19: default_profile: no such profile, got \"clasic\", did you mean \"classic\"?",
+		":7:12: profiles.classic.profiles: unknown field, did you mean \"profiles\"?",
+	})
+	checkErrors(t, "config.toml", "work_time = \n", []string{
+		":1:13: expected value but found '\\n' instead",
+	})
+}
+
+func TestValidateYAML(t *testing.T) {
+	checkErrors(t, "config.yaml", `work_time: 25
+profiles:
+  short:
+    wrk_time: 15
+`, []string{
+		":4:15: profiles.short.wrk_time: unknown field, did you mean \"work_time\"?",
+	})
+	checkErrors(t, "config.yaml", "- 1\n", []string{
+		":1:1: expected a table of values",
+	})
+}
+
+func TestExampleConfigIsValid(t *testing.T) {
+	path := filepath.Join("..", CONFIG_NAME)
+	if errs := CheckConfig(path, ""); len(errs) > 0 {
+		t.Error("Expected", path, "to be valid. Got:", errs)
+	}
+}
+
+func TestCheckConfigDoesNotCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if errs := CheckConfig(path, ""); len(errs) != 1 {
+		t.Error("Expected a missing file error. Got:", errs)
+	}
+	if fileExists(path) {
+		t.Error("Expected", path, "not to be created")
+	}
+}
+
+func TestClosest(t *testing.T) {
+	options := []string{"work_time", "break_time", "long_break_time"}
+	if got := closest("Work_Tme", options); got != "work_time" {
+		t.Error("Expected work_time. Got:", got)
+	}
+	if got := closest("colour", options); got != "" {
+		t.Error("Expected no suggestion. Got:", got)
+	}
+}
+
+func TestValidateDurations(t *testing.T) {
+	cfg := checkErrors(t, "config.yaml", `work_time: 1h15m
+break_time: 7.5
+long_break_time: 500ms
+`, []string{
+		":3:18: long_break_time: must be at least 1s, got \"500ms\"",
+	})
+	if cfg.WorkTime != 75*60 || cfg.BreakTime != 450 {
+		t.Error("Expected 4500 and 450 seconds. Got:", int(cfg.WorkTime), int(cfg.BreakTime))
+	}
+}
+
+func TestValidateActivities(t *testing.T) {
+	cfg := checkErrors(t, "config.json", `{
+  "short_break_activities": ["Stretch", 5],
+  "long_break_activities": ["Go for a walk"],
+  "activity_order": "shuffle"
+}`, []string{
+		":2:29: short_break_activities: expected a list of strings, got [\"Stretch\",5]",
+		":4:21: activity_order: expected one of random, round_robin, got \"shuffle\"",
+	})
+	if len(cfg.ShortBreakActivities) != len(DEFAULT_SHORT_BREAK_ACTIVITIES) ||
+		cfg.LongBreakActivities[0] != "Go for a walk" || cfg.ActivityOrder != ACTIVITY_ORDER_RANDOM {
+		t.Error("Expected invalid values to keep their defaults. Got:",
+			cfg.ShortBreakActivities, cfg.LongBreakActivities, cfg.ActivityOrder)
+	}
+}
+
+func TestValidateClockRanges(t *testing.T) {
+	cfg := checkErrors(t, "config.json", `{
+  "working_hours": "09:00-17:30",
+  "lunch": "13:00-12:00"
+}`, []string{
+		":3:12: lunch: the end must be after the start, got \"13:00-12:00\"",
+	})
+	if cfg.WorkingHours != "09:00-17:30" || cfg.Lunch != "" {
+		t.Error("Expected the invalid lunch to be dropped. Got:", cfg.WorkingHours, cfg.Lunch)
+	}
+}
+
+func TestValidateVolume(t *testing.T) {
+	cfg := checkErrors(t, "config.json", `{
+  "volume": 150
+}`, []string{
+		":2:13: volume: must be from 0 to 100, got 150",
+	})
+	if cfg.Volume != DEFAULT_VOLUME {
+		t.Error("Expected the default volume to be kept. Got:", cfg.Volume)
+	}
+}
+
+func TestValidateValuesReportsRange(t *testing.T) {
+	cfg := defaultConfig("config.json")
+	cfg.TotalPomodoros = 0
+	errs := cfg.validateValues("config.json")
+	if len(errs) != 1 || errs[0].Error() != "config.json: total_pomodoros: must be at least 1, got 0" {
+		t.Error("Expected total_pomodoros to be reported. Got:", errs)
+	}
+	if cfg.TotalPomodoros != DEFAULT_TOTAL_POMODOROS {
+		t.Error("Expected the default to be kept. Got:", cfg.TotalPomodoros)
+	}
+}
+
+func TestReadArgsReportsRange(t *testing.T) {
+	cfg := defaultConfig("config.json")
+	err := cfg.ReadArgs("", "", "", 0, -3, false)
+	if _, ok := err.(*ValidationError); !ok || err.Error() != "command line: total_pomodoros: must be at least 1, got -3" {
+		t.Error("Expected total_pomodoros to be reported. Got:", err)
+	}
+	err = cfg.ReadArgs("500ms", "", "", 0, 0, false)
+	if err == nil || err.Error() != "command line: work_time: must be at least 1s, got \"500ms\"" {
+		t.Error("Expected work_time to be reported. Got:", err)
+	}
+	cfg = defaultConfig("config.json")
+	err = cfg.ReadArgs("0", "", "", 0, 0, false)
+	if err != nil || cfg.WorkTime != DEFAULT_WORK_TIME {
+		t.Error("Expected a work time of 0 to be ignored. Got:", err, cfg.WorkTime)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"pomodoro/history"
+	"pomodoro/runner"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func printStatus(asJSON bool) int {
+	response, err := runner.SendControl(
+		runner.ControlPath(),
+		runner.ControlRequest{Command: runner.CONTROL_STATUS},
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if asJSON {
+		data, _ := json.MarshalIndent(response.Status, "", "  ")
+		fmt.Println(string(data))
+		return 0
+	}
+	fmt.Println(response.Status)
+	return 0
+}
+
+// Send an action to the running session and print its status afterwards
+func control(action string) int {
+	if action == "" {
+		fmt.Fprintln(os.Stderr, "Expected an action, e.g. pause")
+		return 1
+	}
+	response, err := runner.SendControl(
+		runner.ControlPath(),
+		runner.ControlRequest{Command: runner.CONTROL_ACTION, Action: action},
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if action != runner.ACTION_QUIT {
+		fmt.Println(response.Status)
+	}
+	return 0
+}
+
+// Print the task of the running session, or change it when text is given
+func changeTask(text string, clear bool) int {
+	request := runner.ControlRequest{Command: runner.CONTROL_STATUS}
+	if text != "" || clear {
+		request = runner.ControlRequest{Command: runner.CONTROL_TASK, Task: text}
+	}
+	response, err := runner.SendControl(runner.ControlPath(), request)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if response.Status.Task == "" {
+		fmt.Println("No task")
+		return 0
+	}
+	fmt.Println(response.Status.Task)
+	return 0
+}
+
+// Read the config and flags and run a session. With preview, or when --until
+// or --for is given, the planned timeline is printed first and the session
+// only starts once it is confirmed. Returns the exit code
+func startSession(path string, profile string, flags sessionFlags, preview bool) int {
+	cfg, errs := runner.ReadConfig(path, profile)
+	if err := cfg.CheckSounds(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	if len(errs) > 0 && !confirmStart("Press Enter to continue or q to exit") {
+		return 0
+	}
+	err := flags.apply(&cfg)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	cfg.Task = *flags.task
+	now := time.Now()
+	if *flags.at != "" {
+		err = cfg.ScheduleStart(*flags.at, now)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+	start := cfg.PlannedStart(now)
+	end, err := runner.PlanEnd(*flags.until, *flags.duration, start)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if preview && end.IsZero() {
+		fmt.Println("Expected --until or --for, e.g. --until 17:00")
+		return 1
+	}
+	if !end.IsZero() {
+		plan, err := cfg.Plan(start, end)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		printPlan(plan)
+		if !confirmStart("Press Enter to start or q to exit") {
+			return 0
+		}
+		cfg.ApplyPlan(plan)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go runner.Run(&wg, &cfg)
+	wg.Wait()
+	return 0
+}
+
+// Print each phase with its clock times, e.g. "09:00-09:25  Work"
+func printPlan(plan runner.SessionPlan) {
+	for _, phase := range plan.Phases {
+		fmt.Println(
+			phase.Start.Format(runner.CLOCK_LAYOUT) + "-" + phase.End.Format(runner.CLOCK_LAYOUT) +
+				"  " + history.Entry{Phase: phase.Phase}.Summary(),
+		)
+	}
+	fmt.Println(
+		strconv.Itoa(plan.Pomodoros) + " pomodoros, done at " + plan.End().Format(runner.CLOCK_LAYOUT) +
+			" (target " + plan.Target.Format(runner.CLOCK_LAYOUT) + ")",
+	)
+}
+
+// Wait for Enter. Returns false when q is typed instead
+func confirmStart(prompt string) bool {
+	fmt.Println(prompt)
+	var input string
+	fmt.Scanln(&input)
+	return strings.ToLower(input) != "q"
+}
@@ -0,0 +1,27 @@
+// Helpers for tests that draw on a tcell simulation screen
+package tcelltest
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Screen contents as text, one line per row with trailing spaces removed
+func ScreenText(screen tcell.SimulationScreen) string {
+	cells, width, height := screen.GetContents()
+	lines := make([]string, 0, height)
+	for row := 0; row < height; row++ {
+		line := ""
+		for col := 0; col < width; col++ {
+			runes := cells[row*width+col].Runes
+			if len(runes) == 0 {
+				line += " "
+				continue
+			}
+			line += string(runes)
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
@@ -2,146 +2,288 @@ package tcellui
 
 import (
 	"fmt"
-	"os"
+	"pomodoro/keys"
+	"pomodoro/view"
 	"sync"
 
 	"github.com/gdamore/tcell/v2"
 )
 
-type Trigger struct {
-	State int
-	Char  rune
+type region struct {
+	action string
+	x1, x2 int
+	y      int
 }
 
-type EventResponses map[Trigger]func()
-
+// Full screen frontend. Implements both view.Renderer and view.InputSource
 type TcellUI struct {
-	Text           string
-	AppState       int
-	style          tcell.Style
-	screen         tcell.Screen
-	eventResponses EventResponses
-	sizeX          int
-	sizeY          int
-	prevText       string
+	mu           sync.Mutex
+	style        tcell.Style
+	buttonStyle  tcell.Style
+	screen       tcell.Screen
+	bindings     keys.Map
+	sizeX        int
+	sizeY        int
+	prevText     string
+	prevButtons  []view.Button
+	buttons      []view.Button
+	regions      []region
+	mouseButtons tcell.ButtonMask
+	done         chan struct{}
+	closeOnce    sync.Once
 }
 
-func NewTcellUI(appState int) *TcellUI {
+// Fails if there is no terminal to draw on
+func NewTcellUI(bindings keys.Map) (*TcellUI, error) {
 	screen, err := tcell.NewScreen()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create screen: %v", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Failed to create screen: %v", err)
 	}
-	err = screen.Init()
+	return NewTcellUIWithScreen(screen, bindings)
+}
+
+// Draw on an existing screen, e.g. a tcell.SimulationScreen in tests. The
+// screen is initialized here
+func NewTcellUIWithScreen(screen tcell.Screen, bindings keys.Map) (*TcellUI, error) {
+	err := screen.Init()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize screen: %v", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Failed to initialize screen: %v", err)
 	}
 	screen.SetStyle(
 		tcell.StyleDefault.Foreground(tcell.ColorWhite).
 			Background(tcell.ColorBlack),
 	)
+	screen.EnableMouse()
 	sizeX, sizeY := screen.Size()
-	eventResponses := EventResponses{}
 	tcellui := &TcellUI{
-		Text:           "",
-		AppState:       appState,
-		style:          tcell.StyleDefault,
-		screen:         screen,
-		eventResponses: eventResponses,
-		sizeX:          sizeX,
-		sizeY:          sizeY,
-		prevText:       "",
+		style:       tcell.StyleDefault,
+		buttonStyle: tcell.StyleDefault.Reverse(true),
+		screen:      screen,
+		bindings:    bindings,
+		sizeX:       sizeX,
+		sizeY:       sizeY,
+		prevText:    "",
+		done:        make(chan struct{}),
 	}
-	return tcellui
+	return tcellui, nil
+}
+
+// Stop listening for events and close the screen
+func (self *TcellUI) Close() {
+	self.closeOnce.Do(func() {
+		self.mu.Lock()
+		close(self.done)
+		self.mu.Unlock()
+		self.screen.Fini()
+	})
+}
+
+func (self *TcellUI) SetBindings(bindings keys.Map) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.bindings = bindings
 }
 
-func (self *TcellUI) AddEventResponse(trigger Trigger, response func()) {
-	self.eventResponses[trigger] = response
+func (self *TcellUI) action(key keys.Key) string {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.bindings.Action(key)
 }
 
-func (self *TcellUI) RemoveEventResponse(trigger Trigger) {
-	delete(self.eventResponses, trigger)
+func (self *TcellUI) handleKey(ev *tcell.EventKey) view.Input {
+	key := keys.FromEvent(ev)
+	return view.Input{Action: self.action(key), Key: key}
 }
 
-// Run this in a goroutine to listen for events
-func (self *TcellUI) Listen(wg *sync.WaitGroup) {
-	done := make(chan struct{})
-	self.sizeX, self.sizeY = self.screen.Size()
-	quit := func() {
+// Returns false if the event isn't a new button press
+func (self *TcellUI) handleMouse(ev *tcell.EventMouse) (view.Input, bool) {
+	buttons := ev.Buttons()
+	// Only respond to the press, not to drags or releases
+	pressed := buttons &^ self.mouseButtons
+	self.mouseButtons = buttons &^ (tcell.WheelUp | tcell.WheelDown |
+		tcell.WheelLeft | tcell.WheelRight)
+	for _, button := range []tcell.ButtonMask{
+		tcell.Button1, tcell.Button2, tcell.Button3, tcell.WheelUp, tcell.WheelDown,
+	} {
+		if pressed&button == 0 {
+			continue
+		}
+		key := keys.MouseKey(button)
+		if button == tcell.Button1 {
+			x, y := ev.Position()
+			if action := self.regionAt(x, y); action != "" {
+				return view.Input{Action: action, Key: key}, true
+			}
+		}
+		return view.Input{Action: self.action(key), Key: key}, true
+	}
+	return view.Input{}, false
+}
+
+func (self *TcellUI) regionAt(x, y int) string {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for _, r := range self.regions {
+		if y == r.y && x >= r.x1 && x < r.x2 {
+			return r.action
+		}
+	}
+	return ""
+}
+
+// Run this in a goroutine to listen for events. Inputs are sent until Close
+// is called
+func (self *TcellUI) Listen(inputs chan<- view.Input) {
+	defer func() {
 		maybePanic := recover()
-		self.screen.Fini()
 		if maybePanic != nil {
+			self.screen.Fini()
 			panic(maybePanic)
 		}
+	}()
+	send := func(input view.Input) {
+		select {
+		case inputs <- input:
+		case <-self.done:
+		}
 	}
-	defer quit()
-	// self.drawText(0, 0, "Press 'q' to quit")
-	go func() {
-		for {
-			ev := self.screen.PollEvent()
-			switch ev := ev.(type) {
-			case *tcell.EventKey:
-				if ev.Key() == tcell.KeyEscape {
-					self.screen.Fini()
-					close(done)
-					return
-				}
-				// Process other key events
-				switch ev.Rune() {
-				case 'q':
-					self.screen.Fini()
-					close(done)
-					return
-				default:
-					r := ev.Rune()
-					for k, v := range self.eventResponses {
-						if k.Char == r && k.State == self.AppState {
-							v()
-						}
-					}
-				}
-			case *tcell.EventResize:
-				self.screen.Sync()
-				self.sizeX, self.sizeY = self.screen.Size()
+	for {
+		ev := self.screen.PollEvent()
+		switch ev := ev.(type) {
+		case nil:
+			return
+		case *tcell.EventKey:
+			send(self.handleKey(ev))
+		case *tcell.EventMouse:
+			if input, ok := self.handleMouse(ev); ok {
+				send(input)
 			}
+		case *tcell.EventResize:
+			self.mu.Lock()
+			self.screen.Sync()
+			self.sizeX, self.sizeY = self.screen.Size()
+			// Redraw on the next render
+			self.prevText = ""
+			self.mu.Unlock()
 		}
-	}()
-	for {
 		select {
-		case <-done:
-			self.screen.Fini()
-			wg.Done()
+		case <-self.done:
 			return
+		default:
 		}
 	}
 }
 
-func (self *TcellUI) drawText(x, y int, text string) {
+// Draw text starting at x, y and return the position after the last rune
+func (self *TcellUI) drawText(x, y int, text string, style tcell.Style) (int, int) {
 	row := y
 	col := x
 	x2 := self.sizeX
 	y2 := self.sizeY
 	for _, r := range []rune(text) {
-		self.screen.SetContent(col, row, r, nil, self.style)
+		if row > y2 {
+			break
+		}
+		if r == '\n' {
+			row++
+			col = x
+			continue
+		}
+		self.screen.SetContent(col, row, r, nil, style)
 		col++
-		if col >= x2 || r == '\n' {
+		if col >= x2 {
 			row++
 			col = x
 		}
-		if row > y2 {
-			break
+	}
+	return col, row
+}
+
+func (self *TcellUI) drawButtons(y int) {
+	self.regions = nil
+	x := 0
+	for _, b := range self.buttons {
+		label := "[ " + b.Label + " ]"
+		width := len([]rune(label))
+		if x > 0 && x+width > self.sizeX {
+			x = 0
+			y += 2
+		}
+		self.drawText(x, y, label, self.buttonStyle)
+		self.regions = append(self.regions, region{b.Action, x, x + width, y})
+		x += width + 1
+	}
+}
+
+func buttonsEqual(a, b []view.Button) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
 	}
+	return true
 }
 
-// Update screen based on what's in TcellUI.Text
-func (self *TcellUI) Update() {
-	if self.Text == self.prevText {
+func text(model view.Model) string {
+	if model.Overlay != "" {
+		return model.Overlay
+	}
+	text := ""
+	if goals := model.GoalText(); goals != "" {
+		text += goals + "\n"
+	}
+	text += model.PomodoroString() + "\n"
+	if interruptions := model.InterruptionText(); interruptions != "" {
+		text += interruptions + "\n"
+	}
+	if breaks := model.BreakString(); breaks != "" {
+		text += breaks + "\n"
+	}
+	if model.Task != "" {
+		text += "Task: " + model.Task + "\n"
+	}
+	if model.Activity != "" {
+		text += "Try: " + model.Activity + "\n"
+	}
+	if model.ProjectedEnd != "" {
+		text += model.ProjectedEnd + "\n"
+	}
+	text += model.TimerText() + "\n\n"
+	text += model.KeyText()
+	if model.Message != "" {
+		text += "\n\n" + model.Message
+	}
+	return text
+}
+
+// Redraw the screen if the model changed since the last render
+func (self *TcellUI) Render(model view.Model) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	select {
+	case <-self.done:
 		return
+	default:
+	}
+	text := text(model)
+	buttons := model.Buttons
+	if model.Overlay != "" {
+		buttons = nil
 	}
+	if text == self.prevText && buttonsEqual(buttons, self.prevButtons) {
+		return
+	}
+	self.buttons = buttons
 	self.screen.Clear()
-	self.drawText(0, 0, self.Text)
+	_, y := self.drawText(0, 0, text, self.style)
+	self.regions = nil
+	if len(self.buttons) > 0 {
+		self.drawButtons(y + 2)
+	}
 	self.screen.Show()
-	self.prevText = self.Text
+	self.prevText = text
+	self.prevButtons = append(self.prevButtons[:0], buttons...)
 }
@@ -0,0 +1,175 @@
+package tcellui
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"pomodoro/keys"
+	"pomodoro/tcellui/tcelltest"
+	"pomodoro/timer"
+	"pomodoro/view"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var update = flag.Bool("update", false, "Update the golden files in testdata")
+
+func testBindings() keys.Map {
+	return keys.Map{
+		"start": {keys.RuneKey('s')},
+		"pause": {keys.RuneKey('p')},
+		"skip":  {keys.RuneKey('k'), keys.MouseKey(tcell.Button2)},
+		"quit":  {keys.RuneKey('q'), {Code: tcell.KeyEscape}},
+	}
+}
+
+func newSimUI(t *testing.T, width, height int) (*TcellUI, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	ui, err := NewTcellUIWithScreen(screen, testBindings())
+	if err != nil {
+		t.Fatal("Expected simulation screen to initialize. Got:", err)
+	}
+	screen.SetSize(width, height)
+	ui.sizeX, ui.sizeY = width, height
+	return ui, screen
+}
+
+func testModel(state timer.TimerState) view.Model {
+	model := view.Model{
+		State:           state,
+		Remaining:       754,
+		Iterations:      1,
+		MaxIterations:   4,
+		RemainingBreaks: 2,
+		TotalWorkTime:   1500,
+		TotalBreakTime:  300,
+		Markers:         view.Markers{WorkChar: "X", BreakChar: "b", EmptyChar: "-"},
+		Keys: []view.KeyHint{
+			{Keys: "'p'", Label: "Pause"},
+			{Keys: "'q'/Esc", Label: "Quit"},
+		},
+		Buttons: []view.Button{
+			{Label: "Pause", Action: "pause"},
+			{Label: "Skip", Action: "skip"},
+		},
+		Task: "Write tests",
+	}
+	switch state {
+	case timer.DONE:
+		model.Buttons = nil
+	case timer.SCHEDULED:
+		model.Schedule = "Starts at 09:00"
+		model.Keys = []view.KeyHint{{Keys: "'s'", Label: "Start Now"}}
+		model.Buttons = []view.Button{{Label: "Start Now", Action: "start"}}
+	}
+	return model
+}
+
+// Compare against testdata/name.golden, or rewrite it when run with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		err := os.WriteFile(path, []byte(got), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("Failed to read golden file. Run with -update to create it:", err)
+	}
+	if got != string(want) {
+		t.Errorf("Screen doesn't match %s\nExpected:\n%s\nGot:\n%s", path, want, got)
+	}
+}
+
+func TestRenderEveryState(t *testing.T) {
+	for state := timer.STOPPED; state <= timer.SCHEDULED; state++ {
+		ui, screen := newSimUI(t, 40, 12)
+		ui.Render(testModel(state))
+		assertGolden(t, "render_"+strings.ToLower(state.String()), tcelltest.ScreenText(screen))
+		ui.Close()
+	}
+}
+
+func TestRenderOverlay(t *testing.T) {
+	ui, screen := newSimUI(t, 40, 12)
+	defer ui.Close()
+	model := testModel(timer.WORK)
+	model.Overlay = "Reset the current phase?\n\n'y': Yes\nAny other key: No"
+	ui.Render(model)
+	assertGolden(t, "render_overlay", tcelltest.ScreenText(screen))
+}
+
+// Read the next input or fail after a second
+func nextInput(t *testing.T, inputs <-chan view.Input) view.Input {
+	t.Helper()
+	select {
+	case input := <-inputs:
+		return input
+	case <-time.After(time.Second):
+		t.Fatal("Expected an input")
+	}
+	return view.Input{}
+}
+
+func TestResize(t *testing.T) {
+	ui, screen := newSimUI(t, 40, 12)
+	defer ui.Close()
+	inputs := make(chan view.Input)
+	go ui.Listen(inputs)
+	model := testModel(timer.WORK)
+	ui.Render(model)
+	assertGolden(t, "resize_before", tcelltest.ScreenText(screen))
+	screen.SetSize(16, 14)
+	screen.PostEvent(tcell.NewEventResize(16, 14))
+	// Events are handled in order so the resize is done once this arrives
+	screen.InjectKey(tcell.KeyRune, 'x', tcell.ModNone)
+	nextInput(t, inputs)
+	// The same model has to be drawn again to fit the new size
+	ui.Render(model)
+	assertGolden(t, "resize_after", tcelltest.ScreenText(screen))
+}
+
+func TestListen(t *testing.T) {
+	ui, screen := newSimUI(t, 40, 12)
+	defer ui.Close()
+	inputs := make(chan view.Input)
+	go ui.Listen(inputs)
+	ui.Render(testModel(timer.WORK))
+
+	screen.InjectKey(tcell.KeyRune, 'p', tcell.ModNone)
+	if input := nextInput(t, inputs); input.Action != "pause" {
+		t.Error("Expected 'p' to pause. Got:", input.Action)
+	}
+	screen.InjectKey(tcell.KeyEscape, 0, tcell.ModNone)
+	if input := nextInput(t, inputs); input.Action != "quit" {
+		t.Error("Expected Esc to quit. Got:", input.Action)
+	}
+	screen.InjectKey(tcell.KeyDown, 0, tcell.ModNone)
+	input := nextInput(t, inputs)
+	if input.Action != "" || input.Key.Code != tcell.KeyDown {
+		t.Error("Expected an unbound Down key. Got:", input)
+	}
+
+	// Buttons are drawn two rows below the text, see render_work.golden
+	screen.InjectMouse(12, 8, tcell.Button1, tcell.ModNone)
+	screen.InjectMouse(12, 8, tcell.ButtonNone, tcell.ModNone)
+	if input := nextInput(t, inputs); input.Action != "skip" {
+		t.Error("Expected clicking Skip to skip. Got:", input.Action)
+	}
+	screen.InjectMouse(30, 1, tcell.Button1, tcell.ModNone)
+	screen.InjectMouse(30, 1, tcell.ButtonNone, tcell.ModNone)
+	if input := nextInput(t, inputs); input.Action != "" {
+		t.Error("Expected clicking outside a button to do nothing. Got:", input.Action)
+	}
+	screen.InjectMouse(30, 1, tcell.Button2, tcell.ModNone)
+	if input := nextInput(t, inputs); input.Action != "skip" {
+		t.Error("Expected a right click to skip. Got:", input.Action)
+	}
+}
@@ -17,6 +17,8 @@ const (
 	LBREAK
 	LBREAK_PAUSED
 	DONE
+	// Waiting to start work at a set time.
+	SCHEDULED
 )
 
 func (t TimerState) String() string {
@@ -43,6 +45,8 @@ func (t TimerState) String() string {
 		return "LBREAK_PAUSED"
 	case DONE:
 		return "DONE"
+	case SCHEDULED:
+		return "SCHEDULED"
 	default:
 		return "UNKNOWN"
 	}
@@ -61,7 +65,29 @@ type Timer struct {
 	remainingBreaks  int
 	breaksLength     int // in seconds
 	AutoAdvance      bool
-	timerState       TimerState
+	// Whether skipped work counts towards the long break interval.
+	CountSkippedWork bool
+	// Work phases counted towards the next long break.
+	sinceLongBreak int
+	// Set while Skip finishes a phase.
+	skipping bool
+	voided   int
+	// Seconds until work starts while SCHEDULED.
+	scheduleLeft int
+	timerState   TimerState
+	aborted      bool
+	// Seconds spent paused or waiting in a PRE_ state, for the session and
+	// for the current phase. A phase's waiting time is the time before it
+	// was started
+	pausedTime       int
+	waitingTime      int
+	phasePausedTime  int
+	phaseWaitingTime int
+	// Lengths set with SetFutureDurations for phases that were in progress.
+	// 0 when there is nothing pending
+	pendingWork   int
+	pendingSbreak int
+	pendingLbreak int
 }
 
 func NewTimer(
@@ -85,6 +111,7 @@ func NewTimer(
 		remainingBreaks:  maxWorkIter - 1,
 		breaksLength:     0,
 		AutoAdvance:      autoAdvance,
+		CountSkippedWork: true,
 		timerState:       STOPPED,
 	}
 }
@@ -93,12 +120,27 @@ func NewTimer(
 // This should be called once a second in a goroutine until the timer state is
 // DONE at which point it will stop doing anything.
 func (self *Timer) Tick() TimerState {
+	before := self.timerState
 	switch self.timerState {
+	case WORK_PAUSED, SBREAK_PAUSED, LBREAK_PAUSED:
+		self.pausedTime++
+		self.phasePausedTime++
+	case PRE_WORK, PRE_SBREAK, PRE_LBREAK:
+		self.waitingTime++
+		self.phaseWaitingTime++
+	case SCHEDULED:
+		// Planned, so it isn't counted as waiting.
+		self.scheduleLeft--
+		if self.scheduleLeft <= 0 {
+			self.timerState = WORK
+		}
 	case WORK:
 		if self.counter >= self.maxWorkCounter {
+			if !self.skipping || self.CountSkippedWork {
+				self.sinceLongBreak++
+			}
 			if self.workIter < self.maxWorkIter-1 {
-				if self.workIter == 0 ||
-					(self.workIter > 0 && (self.workIter+1)%self.workChunk != 0) {
+				if self.workIter == 0 || self.sinceLongBreak < self.workChunk {
 					self.counter = 0
 					self.workIter++
 					if self.AutoAdvance {
@@ -106,8 +148,9 @@ func (self *Timer) Tick() TimerState {
 					} else {
 						self.timerState = PRE_SBREAK
 					}
-				} else if (self.workIter+1)%self.workChunk == 0 {
+				} else {
 					self.counter = 0
+					self.sinceLongBreak = 0
 					self.workIter++
 					if self.AutoAdvance {
 						self.timerState = LBREAK
@@ -152,13 +195,23 @@ func (self *Timer) Tick() TimerState {
       self.totalBreakTime++
 		}
 	}
+	if self.timerState != before {
+		self.resetPhaseTimes()
+	}
+	self.applyPendingDurations()
 	return self.timerState
 }
 
+func (self *Timer) resetPhaseTimes() {
+	self.phasePausedTime = 0
+	self.phaseWaitingTime = 0
+}
+
 func (self *Timer) Start() TimerState {
 	switch self.timerState {
-	case STOPPED, WORK_PAUSED, PRE_WORK:
+	case STOPPED, WORK_PAUSED, PRE_WORK, SCHEDULED:
 		self.timerState = WORK
+		self.scheduleLeft = 0
 	case SBREAK_PAUSED, PRE_SBREAK:
 		self.timerState = SBREAK
 	case LBREAK_PAUSED, PRE_LBREAK:
@@ -167,8 +220,13 @@ func (self *Timer) Start() TimerState {
 	return self.timerState
 }
 
+// End the session early. The session is marked as aborted.
 func (self *Timer) Stop() TimerState {
+	if self.timerState == DONE {
+		return self.timerState
+	}
 	self.timerState = DONE
+	self.aborted = true
 	return self.timerState
 }
 
@@ -184,14 +242,96 @@ func (self *Timer) Pause() TimerState {
 	return self.timerState
 }
 
+// Restart the whole session as if the timer was just created.
 func (self *Timer) Reset() TimerState {
 	self.counter = 0
 	self.workIter = 0
+	self.remainingBreaks = self.maxWorkIter - 1
+	self.breaksLength = 0
+	self.totalWorkTime = 0
+	self.totalBreakTime = 0
+	self.aborted = false
+	self.sinceLongBreak = 0
+	self.voided = 0
+	self.scheduleLeft = 0
+	self.pausedTime = 0
+	self.waitingTime = 0
+	self.resetPhaseTimes()
 	self.timerState = STOPPED
+	self.applyPendingDurations()
 	return self.timerState
 }
 
+// Restart the current phase from the beginning and wait for it to be started
+// again. Time already spent in the phase still counts towards the totals.
+func (self *Timer) ResetPhase() TimerState {
+	switch self.timerState {
+	case WORK, WORK_PAUSED:
+		self.counter = 0
+		if self.workIter == 0 {
+			self.timerState = STOPPED
+		} else {
+			self.timerState = PRE_WORK
+		}
+	case SBREAK, SBREAK_PAUSED:
+		self.counter = 0
+		self.timerState = PRE_SBREAK
+	case LBREAK, LBREAK_PAUSED:
+		self.counter = 0
+		self.timerState = PRE_LBREAK
+	}
+	self.resetPhaseTimes()
+	self.applyPendingDurations()
+	return self.timerState
+}
+
+// Change the length of each phase in seconds. This also applies to the
+// current phase so a shorter phase may end on the next tick.
+func (self *Timer) SetDurations(maxWorkCounter, maxSbreakCounter, maxLbreakCounter int) {
+	self.maxWorkCounter = maxWorkCounter
+	self.maxSbreakCounter = maxSbreakCounter
+	self.maxLbreakCounter = maxLbreakCounter
+}
+
+// Change the length of each phase in seconds without changing the phase in
+// progress. Its new length is used from the next time it starts.
+func (self *Timer) SetFutureDurations(maxWorkCounter, maxSbreakCounter, maxLbreakCounter int) {
+	self.pendingWork = maxWorkCounter
+	self.pendingSbreak = maxSbreakCounter
+	self.pendingLbreak = maxLbreakCounter
+	self.applyPendingDurations()
+}
+
+func (self *Timer) applyPendingDurations() {
+	state := self.timerState
+	if self.pendingWork > 0 && state != WORK && state != WORK_PAUSED {
+		self.maxWorkCounter = self.pendingWork
+		self.pendingWork = 0
+	}
+	if self.pendingSbreak > 0 && state != SBREAK && state != SBREAK_PAUSED {
+		self.maxSbreakCounter = self.pendingSbreak
+		self.pendingSbreak = 0
+	}
+	if self.pendingLbreak > 0 && state != LBREAK && state != LBREAK_PAUSED {
+		self.maxLbreakCounter = self.pendingLbreak
+		self.pendingLbreak = 0
+	}
+}
+
+// Change the total number of pomodoros. Breaks already taken are kept.
+func (self *Timer) SetMaxWorkIter(maxWorkIter int) {
+	breaksTaken := self.maxWorkIter - 1 - self.remainingBreaks
+	self.maxWorkIter = maxWorkIter
+	self.remainingBreaks = maxWorkIter - 1 - breaksTaken
+}
+
+func (self *Timer) SetWorkChunk(workChunk int) {
+	self.workChunk = workChunk
+}
+
 func (self *Timer) Skip() TimerState {
+	self.skipping = true
+	defer func() { self.skipping = false }()
 	switch self.timerState {
 	case PRE_WORK:
 		self.Start()
@@ -218,6 +358,103 @@ func (self *Timer) Skip() TimerState {
 	return self.timerState
 }
 
+// Abandon the work phase in progress. It isn't counted as a pomodoro and the
+// next work phase waits to be started. Time already spent still counts
+// towards the totals.
+func (self *Timer) Void() TimerState {
+	if self.timerState != WORK && self.timerState != WORK_PAUSED {
+		return self.timerState
+	}
+	self.voided++
+	return self.ResetPhase()
+}
+
+// Take seconds of work back off the work phase in progress, e.g. time nobody
+// was at the computer. They count as paused time instead. Returns the number
+// of seconds taken off.
+func (self *Timer) DiscardWork(seconds int) int {
+	if self.timerState != WORK && self.timerState != WORK_PAUSED {
+		return 0
+	}
+	if seconds > self.counter {
+		seconds = self.counter
+	}
+	self.counter -= seconds
+	self.totalWorkTime -= seconds
+	self.pausedTime += seconds
+	self.phasePausedTime += seconds
+	return seconds
+}
+
+// Wait seconds before starting the next work phase. Only works before a
+// work phase has started.
+func (self *Timer) Schedule(seconds int) TimerState {
+	if seconds <= 0 {
+		return self.timerState
+	}
+	switch self.timerState {
+	case STOPPED, PRE_WORK, SCHEDULED:
+		self.timerState = SCHEDULED
+		self.scheduleLeft = seconds
+	}
+	return self.timerState
+}
+
+// Seconds until work starts while SCHEDULED.
+func (self *Timer) ScheduleLeft() int {
+	return self.scheduleLeft
+}
+
+// Seconds until the session is done if every phase runs in full and each one
+// starts as soon as the one before it ends. Pausing or waiting to start a
+// phase pushes the end back.
+func (self *Timer) RemainingTime() int {
+	total := 0
+	workLeft := self.maxWorkCounter
+	switch self.timerState {
+	case DONE:
+		return 0
+	case SCHEDULED:
+		total += self.scheduleLeft
+	case WORK, WORK_PAUSED:
+		workLeft = self.maxWorkCounter - self.counter
+	case PRE_SBREAK:
+		total += self.maxSbreakCounter
+	case SBREAK, SBREAK_PAUSED:
+		total += self.maxSbreakCounter - self.counter
+	case PRE_LBREAK:
+		total += self.maxLbreakCounter
+	case LBREAK, LBREAK_PAUSED:
+		total += self.maxLbreakCounter - self.counter
+	}
+	since := self.sinceLongBreak
+	for iter := self.workIter; iter < self.maxWorkIter; iter++ {
+		total += workLeft
+		workLeft = self.maxWorkCounter
+		since++
+		if iter == self.maxWorkIter-1 {
+			break
+		}
+		if iter == 0 || since < self.workChunk {
+			total += self.maxSbreakCounter
+		} else {
+			total += self.maxLbreakCounter
+			since = 0
+		}
+	}
+	return total
+}
+
+// Number of work phases abandoned with Void.
+func (self *Timer) Voided() int {
+	return self.voided
+}
+
+// Whether the session was ended early with Stop
+func (self *Timer) Aborted() bool {
+	return self.aborted
+}
+
 func (self *Timer) TimerState() TimerState {
 	return self.timerState
 }
@@ -228,6 +465,11 @@ func (self *Timer) Counter() int {
 
 // Convert seconds to human readable time string.
 func (self *Timer) TimeString(seconds int) string {
+	return TimeString(seconds)
+}
+
+// Convert seconds to human readable time string.
+func TimeString(seconds int) string {
 	if seconds < 60 {
 		return fmt.Sprintf("%02ds", seconds)
 	} else if seconds < 3600 {
@@ -237,6 +479,25 @@ func (self *Timer) TimeString(seconds int) string {
 	}
 }
 
+// Convert seconds to a short time string like "25m", "1m30s" or "1h15m".
+func ShortTimeString(seconds int) string {
+	if seconds <= 0 {
+		return "0s"
+	}
+	text := ""
+	hours, minutes, secs := seconds/3600, seconds%3600/60, seconds%60
+	if hours > 0 {
+		text += fmt.Sprintf("%dh", hours)
+	}
+	if minutes > 0 {
+		text += fmt.Sprintf("%dm", minutes)
+	}
+	if secs > 0 {
+		text += fmt.Sprintf("%ds", secs)
+	}
+	return text
+}
+
 func (self *Timer) WorkIter() int {
 	return self.workIter
 }
@@ -273,6 +534,26 @@ func (self *Timer) TotalWorkTime() int {
   return self.totalWorkTime
 }
 
+// Seconds spent in a paused state this session.
+func (self *Timer) TotalPausedTime() int {
+	return self.pausedTime
+}
+
+// Seconds spent waiting for a phase to be started this session.
+func (self *Timer) TotalWaitingTime() int {
+	return self.waitingTime
+}
+
+// Seconds the current phase has been paused for.
+func (self *Timer) PhasePausedTime() int {
+	return self.phasePausedTime
+}
+
+// Seconds spent waiting before the current phase was started.
+func (self *Timer) PhaseWaitingTime() int {
+	return self.phaseWaitingTime
+}
+
 func (self *Timer) TotalBreakTime() int {
   return self.totalBreakTime
 }
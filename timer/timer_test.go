@@ -177,3 +177,271 @@ func TestSkips(t *testing.T) {
     t.Error("Expected state to be DONE. Got:", tmr.TimerState())
   }
 }
+
+func TestSetMaxWorkIter(t *testing.T) {
+	tmr := NewTimer(1, 1, 1, 4, 2, false)
+	tmr.Start()
+	tmr.Skip()
+	tmr.Skip()
+	if tmr.RemainingBreaks() != 2 {
+		t.Error("Expected remaining breaks to be 2. Got:", tmr.RemainingBreaks())
+	}
+	tmr.SetMaxWorkIter(6)
+	if tmr.RemainingBreaks() != 4 {
+		t.Error("Expected remaining breaks to be 4. Got:", tmr.RemainingBreaks())
+	}
+	tmr.SetMaxWorkIter(2)
+	if tmr.RemainingBreaks() != 0 {
+		t.Error("Expected remaining breaks to be 0. Got:", tmr.RemainingBreaks())
+	}
+	tmr.Skip()
+	if tmr.TimerState() != DONE {
+		t.Error("Expected state to be DONE. Got:", tmr.TimerState())
+	}
+}
+
+func TestReset(t *testing.T) {
+	tmr := NewTimer(2, 1, 1, 3, 2, false)
+	tmr.Start()
+	tmr.Tick()
+	tmr.Skip()
+	tmr.Skip()
+	tmr.Stop()
+	if !tmr.Aborted() {
+		t.Error("Expected session to be aborted")
+	}
+	state := tmr.Reset()
+	if state != STOPPED {
+		t.Error("Expected state to be STOPPED. Got:", state)
+	}
+	if tmr.Aborted() {
+		t.Error("Expected session to not be aborted after reset")
+	}
+	if tmr.WorkIter() != 0 || tmr.RemainingBreaks() != 2 {
+		t.Error(
+			"Expected 0 pomodoros and 2 breaks. Got:",
+			tmr.WorkIter(),
+			tmr.RemainingBreaks(),
+		)
+	}
+	if tmr.TotalWorkTime() != 0 || tmr.TotalBreakTime() != 0 {
+		t.Error("Expected totals to be reset")
+	}
+}
+
+func TestResetPhase(t *testing.T) {
+	tmr := NewTimer(3, 2, 2, 3, 2, false)
+	tmr.Start()
+	tmr.Tick()
+	state := tmr.ResetPhase()
+	if state != STOPPED || tmr.Counter() != 0 {
+		t.Error("Expected STOPPED with counter 0. Got:", state, tmr.Counter())
+	}
+	tmr.Start()
+	tmr.Skip()
+	tmr.Start()
+	tmr.Tick()
+	tmr.Pause()
+	state = tmr.ResetPhase()
+	if state != PRE_SBREAK || tmr.Counter() != 0 {
+		t.Error("Expected PRE_SBREAK with counter 0. Got:", state, tmr.Counter())
+	}
+	if tmr.WorkIter() != 1 {
+		t.Error("Expected work iterations to be 1. Got:", tmr.WorkIter())
+	}
+}
+
+func TestSetFutureDurations(t *testing.T) {
+	tmr := NewTimer(3, 2, 2, 3, 2, false)
+	tmr.Start()
+	tmr.Tick()
+	tmr.SetFutureDurations(5, 4, 6)
+	if tmr.MaxWorkCounter() != 3 {
+		t.Error("Expected the running work phase to keep its length 3. Got:", tmr.MaxWorkCounter())
+	}
+	if tmr.MaxSbreakCounter() != 4 || tmr.MaxLbreakCounter() != 6 {
+		t.Error("Expected breaks of 4 and 6. Got:", tmr.MaxSbreakCounter(), tmr.MaxLbreakCounter())
+	}
+	tmr.Pause()
+	tmr.Tick()
+	if tmr.MaxWorkCounter() != 3 {
+		t.Error("Expected the paused work phase to keep its length 3. Got:", tmr.MaxWorkCounter())
+	}
+	tmr.Start()
+	state := tmr.Skip()
+	if state != PRE_SBREAK || tmr.MaxWorkCounter() != 5 {
+		t.Error("Expected PRE_SBREAK with the next work phase 5. Got:", state, tmr.MaxWorkCounter())
+	}
+}
+
+func TestPausedAndWaitingTime(t *testing.T) {
+	tmr := NewTimer(2, 2, 2, 3, 2, false)
+	tmr.Tick()
+	if tmr.TotalWaitingTime() != 0 {
+		t.Error("Expected no waiting before the session starts. Got:", tmr.TotalWaitingTime())
+	}
+	tmr.Start()
+	tmr.Tick()
+	tmr.Pause()
+	tmr.Tick()
+	tmr.Tick()
+	if tmr.PhasePausedTime() != 2 || tmr.TotalPausedTime() != 2 {
+		t.Error("Expected 2s paused. Got:", tmr.PhasePausedTime(), tmr.TotalPausedTime())
+	}
+	tmr.Start()
+	tmr.Tick()
+	tmr.Tick()
+	if tmr.TimerState() != PRE_SBREAK || tmr.PhasePausedTime() != 0 {
+		t.Error("Expected the next phase to start over. Got:", tmr.TimerState(), tmr.PhasePausedTime())
+	}
+	tmr.Tick()
+	tmr.Tick()
+	tmr.Tick()
+	tmr.Start()
+	tmr.Pause()
+	tmr.Tick()
+	if tmr.PhaseWaitingTime() != 3 || tmr.PhasePausedTime() != 1 {
+		t.Error("Expected 3s waiting and 1s paused for the break. Got:",
+			tmr.PhaseWaitingTime(), tmr.PhasePausedTime())
+	}
+	if tmr.TotalWaitingTime() != 3 || tmr.TotalPausedTime() != 3 {
+		t.Error("Expected 3s waiting and 3s paused in total. Got:",
+			tmr.TotalWaitingTime(), tmr.TotalPausedTime())
+	}
+	if tmr.TotalWorkTime() != 2 || tmr.TotalBreakTime() != 0 {
+		t.Error("Expected pauses not to count as work or breaks. Got:",
+			tmr.TotalWorkTime(), tmr.TotalBreakTime())
+	}
+	tmr.Reset()
+	if tmr.TotalWaitingTime() != 0 || tmr.TotalPausedTime() != 0 || tmr.PhasePausedTime() != 0 {
+		t.Error("Expected the times to be reset")
+	}
+}
+
+func TestVoid(t *testing.T) {
+	tmr := NewTimer(2, 1, 1, 3, 2, false)
+	tmr.Start()
+	tmr.Tick()
+	if tmr.Void() != STOPPED {
+		t.Error("Expected the first pomodoro to go back to STOPPED. Got:", tmr.TimerState())
+	}
+	if tmr.WorkIter() != 0 || tmr.Voided() != 1 || tmr.Counter() != 0 {
+		t.Error("Expected the pomodoro not to count. Got:", tmr.WorkIter(), tmr.Voided(), tmr.Counter())
+	}
+	if tmr.TotalWorkTime() != 1 {
+		t.Error("Expected the time spent to be kept. Got:", tmr.TotalWorkTime())
+	}
+	tmr.Start()
+	tmr.Skip()
+	tmr.Skip()
+	tmr.Start()
+	tmr.Pause()
+	if tmr.Void() != PRE_WORK || tmr.WorkIter() != 1 {
+		t.Error("Expected to wait for the second pomodoro. Got:", tmr.TimerState(), tmr.WorkIter())
+	}
+	if tmr.Skip() != PRE_LBREAK {
+		t.Error("Expected a long break after 2 pomodoros. Got:", tmr.TimerState())
+	}
+	if tmr.Void() != PRE_LBREAK || tmr.Voided() != 2 {
+		t.Error("Expected breaks not to be voided. Got:", tmr.TimerState(), tmr.Voided())
+	}
+	tmr.Reset()
+	if tmr.Voided() != 0 {
+		t.Error("Expected the voided count to be reset. Got:", tmr.Voided())
+	}
+}
+
+func TestSkippedWorkNotCounted(t *testing.T) {
+	tmr := NewTimer(1, 1, 1, 5, 2, false)
+	tmr.CountSkippedWork = false
+	tmr.Start()
+	tmr.Skip()
+	tmr.Skip()
+	tmr.Start()
+	tmr.Tick()
+	tmr.Tick()
+	if tmr.TimerState() != PRE_SBREAK {
+		t.Error("Expected the skipped pomodoro not to count towards the long break. Got:", tmr.TimerState())
+	}
+	tmr.Skip()
+	tmr.Start()
+	tmr.Tick()
+	tmr.Tick()
+	if tmr.TimerState() != PRE_LBREAK || tmr.WorkIter() != 3 {
+		t.Error("Expected a long break after 2 finished pomodoros. Got:", tmr.TimerState(), tmr.WorkIter())
+	}
+}
+
+func TestDiscardWork(t *testing.T) {
+	tmr := NewTimer(10, 2, 2, 3, 2, false)
+	if tmr.DiscardWork(5) != 0 {
+		t.Error("Expected nothing to discard before work starts")
+	}
+	tmr.Start()
+	for i := 0; i < 4; i++ {
+		tmr.Tick()
+	}
+	tmr.Pause()
+	if discarded := tmr.DiscardWork(3); discarded != 3 || tmr.Counter() != 1 || tmr.TotalWorkTime() != 1 {
+		t.Error("Expected 3s to be taken off. Got:", discarded, tmr.Counter(), tmr.TotalWorkTime())
+	}
+	if tmr.PhasePausedTime() != 3 || tmr.TotalPausedTime() != 3 {
+		t.Error("Expected the discarded time to count as paused. Got:", tmr.PhasePausedTime())
+	}
+	if discarded := tmr.DiscardWork(5); discarded != 1 || tmr.Counter() != 0 {
+		t.Error("Expected no more than the work done to be taken off. Got:", discarded, tmr.Counter())
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	tmr := NewTimer(2, 1, 1, 3, 2, false)
+	if tmr.Schedule(2) != SCHEDULED || tmr.ScheduleLeft() != 2 {
+		t.Fatal("Expected to wait 2s. Got:", tmr.TimerState(), tmr.ScheduleLeft())
+	}
+	tmr.Tick()
+	if tmr.TimerState() != SCHEDULED || tmr.TotalWaitingTime() != 0 {
+		t.Error("Expected to keep waiting without counting it. Got:", tmr.TimerState(), tmr.TotalWaitingTime())
+	}
+	tmr.Tick()
+	if tmr.TimerState() != WORK || tmr.Counter() != 0 {
+		t.Error("Expected work to start on time. Got:", tmr.TimerState(), tmr.Counter())
+	}
+	if tmr.Schedule(5) != WORK {
+		t.Error("Expected work in progress not to be scheduled. Got:", tmr.TimerState())
+	}
+	tmr.Skip()
+	tmr.Skip()
+	tmr.Schedule(10)
+	if tmr.Start() != WORK || tmr.ScheduleLeft() != 0 {
+		t.Error("Expected work to start straight away. Got:", tmr.TimerState(), tmr.ScheduleLeft())
+	}
+}
+
+func TestRemainingTime(t *testing.T) {
+	// Work 3s, short break 1s, long break 2s, 3 pomodoros, long break after 2
+	tmr := NewTimer(3, 1, 2, 3, 2, false)
+	if tmr.RemainingTime() != 3+1+3+2+3 {
+		t.Error("Expected 12s for the whole session. Got:", tmr.RemainingTime())
+	}
+	tmr.Start()
+	tmr.Tick()
+	tmr.Pause()
+	tmr.Tick()
+	if tmr.RemainingTime() != 11 {
+		t.Error("Expected pauses not to count. Got:", tmr.RemainingTime())
+	}
+	tmr.Start()
+	tmr.Skip()
+	if tmr.TimerState() != PRE_SBREAK || tmr.RemainingTime() != 1+3+2+3 {
+		t.Error("Expected 9s from the first break. Got:", tmr.TimerState(), tmr.RemainingTime())
+	}
+	tmr.Skip()
+	tmr.Skip()
+	if tmr.TimerState() != PRE_LBREAK || tmr.RemainingTime() != 2+3 {
+		t.Error("Expected 5s from the long break. Got:", tmr.TimerState(), tmr.RemainingTime())
+	}
+	tmr.Stop()
+	if tmr.RemainingTime() != 0 {
+		t.Error("Expected nothing left once done. Got:", tmr.RemainingTime())
+	}
+}
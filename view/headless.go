@@ -0,0 +1,95 @@
+package view
+
+import (
+	"pomodoro/keys"
+	"sync"
+)
+
+// Renderer and input source that doesn't draw anything. Tests use it to send
+// inputs to a session and check what would have been rendered.
+type Headless struct {
+	mu       sync.Mutex
+	models   []Model
+	bindings keys.Map
+	inputs   chan Input
+	done     chan struct{}
+	once     sync.Once
+}
+
+func NewHeadless() *Headless {
+	return &Headless{
+		inputs: make(chan Input),
+		done:   make(chan struct{}),
+	}
+}
+
+func (self *Headless) Render(model Model) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.models = append(self.models, model)
+}
+
+// The most recently rendered model
+func (self *Headless) Last() Model {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if len(self.models) == 0 {
+		return Model{}
+	}
+	return self.models[len(self.models)-1]
+}
+
+// Number of times Render was called
+func (self *Headless) Renders() int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return len(self.models)
+}
+
+// Send an input as if the user did it. Blocks until it is read by Listen
+func (self *Headless) Send(input Input) {
+	select {
+	case self.inputs <- input:
+	case <-self.done:
+	}
+}
+
+func (self *Headless) Listen(inputs chan<- Input) {
+	for {
+		select {
+		case input := <-self.inputs:
+			select {
+			case inputs <- input:
+			case <-self.done:
+				return
+			}
+		case <-self.done:
+			return
+		}
+	}
+}
+
+// Inputs are sent with Send so the bindings are only kept for Bindings
+func (self *Headless) SetBindings(bindings keys.Map) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.bindings = bindings
+}
+
+// The bindings last passed to SetBindings
+func (self *Headless) Bindings() keys.Map {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.bindings
+}
+
+func (self *Headless) Close() {
+	self.once.Do(func() {
+		close(self.done)
+	})
+}
+
+// Closed after Close is called
+func (self *Headless) Done() <-chan struct{} {
+	return self.done
+}
@@ -0,0 +1,233 @@
+// View model shared by the frontends. The runner builds a Model from the timer
+// and hands it to a Renderer, and reads what the user does from an
+// InputSource, so a frontend never has to know about the timer or the config.
+package view
+
+import (
+	"pomodoro/keys"
+	"pomodoro/timer"
+	"strconv"
+	"strings"
+)
+
+type Markers struct {
+	WorkChar  string
+	BreakChar string
+	EmptyChar string
+}
+
+// Keys that trigger an action in the current state, e.g. {"'p'", "Pause"}
+type KeyHint struct {
+	Keys  string
+	Label string
+}
+
+const (
+	PERIOD_DAY  = "day"
+	PERIOD_WEEK = "week"
+)
+
+// Progress towards a daily or weekly target
+type Goal struct {
+	// PERIOD_DAY or PERIOD_WEEK
+	Period string
+	Done   int
+	Target int
+	// Done and Target are seconds of work instead of pomodoros
+	Time bool
+}
+
+func (self Goal) Reached() bool {
+	return self.Done >= self.Target
+}
+
+// e.g. "6/10 🍅" or "2h30m/4h"
+func (self Goal) Text(workChar string) string {
+	if self.Time {
+		return timer.ShortTimeString(self.Done) + "/" + timer.ShortTimeString(self.Target)
+	}
+	return strconv.Itoa(self.Done) + "/" + strconv.Itoa(self.Target) + " " + workChar
+}
+
+// e.g. "Today: 6/10 🍅 2h30m/4h  Week: 20/40 🍅". Returns "" when there are no
+// goals
+func GoalText(goals []Goal, workChar string) string {
+	parts := []string{}
+	period := ""
+	for _, goal := range goals {
+		text := goal.Text(workChar)
+		if goal.Period == period {
+			parts[len(parts)-1] += " " + text
+			continue
+		}
+		period = goal.Period
+		label := "Today: "
+		if period == PERIOD_WEEK {
+			label = "Week: "
+		}
+		parts = append(parts, label+text)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// Interruptions logged during a pomodoro or a session
+type Interruptions struct {
+	Internal int
+	External int
+}
+
+func (self Interruptions) Total() int {
+	return self.Internal + self.External
+}
+
+// e.g. "2 internal, 1 external"
+func (self Interruptions) String() string {
+	return strconv.Itoa(self.Internal) + " internal, " + strconv.Itoa(self.External) + " external"
+}
+
+// A clickable button. Clicking it triggers Action
+type Button struct {
+	Label  string
+	Action string
+}
+
+type Model struct {
+	State           timer.TimerState
+	Remaining       int // in seconds
+	Iterations      int
+	MaxIterations   int
+	RemainingBreaks int
+	TotalWorkTime   int // in seconds
+	TotalBreakTime  int // in seconds
+	// Seconds paused, and waiting for a phase to be started
+	TotalPausedTime  int
+	TotalWaitingTime int
+	Aborted          bool
+	Markers          Markers
+	Keys             []KeyHint
+	Buttons          []Button
+	Task             string
+	Goals            []Goal
+	// Suggested for the break in progress. "" during work
+	Activity string
+	// What a SCHEDULED session waits for, e.g. "Lunch until 13:30"
+	Schedule string
+	// When a planned session will be done at this rate. "" if it wasn't planned
+	ProjectedEnd string
+	// In the current pomodoro
+	Interruptions      Interruptions
+	TotalInterruptions Interruptions
+	// A short notice, e.g. that the config was reloaded. "" if there is none
+	Message string
+	// Drawn instead of the timer when set, e.g. the help or settings screen
+	Overlay string
+}
+
+// Something the user did. Action is "" if the key isn't bound to anything
+type Input struct {
+	Action string
+	Key    keys.Key
+	// Text typed in line mode that isn't a command, or what follows the action,
+	// e.g. the note in "internal_interruption phone"
+	Text string
+	// Set for each line typed in line mode, even an empty one. Line is the
+	// whole of it, for prompts that take a line as it is
+	FromLine bool
+	Line     string
+}
+
+type Renderer interface {
+	Render(model Model)
+	Close()
+}
+
+type InputSource interface {
+	// Send inputs until Close is called. The session ends when this returns
+	Listen(inputs chan<- Input)
+	// Use other key bindings from the next input on
+	SetBindings(bindings keys.Map)
+	Close()
+}
+
+func (self Model) Phase() string {
+	switch self.State {
+	case timer.STOPPED, timer.PRE_WORK, timer.WORK, timer.WORK_PAUSED, timer.SCHEDULED:
+		return "Work"
+	case timer.PRE_SBREAK, timer.SBREAK, timer.SBREAK_PAUSED:
+		return "Short Break"
+	case timer.PRE_LBREAK, timer.LBREAK, timer.LBREAK_PAUSED:
+		return "Long Break"
+	default:
+		return ""
+	}
+}
+
+func (self Model) PomodoroString() string {
+	pomodoros := ""
+	for i := 0; i < self.Iterations; i++ {
+		pomodoros += self.Markers.WorkChar + " "
+	}
+	for i := 0; i < self.MaxIterations-self.Iterations; i++ {
+		pomodoros += self.Markers.EmptyChar + " "
+	}
+	return "Pomodoros: " + pomodoros
+}
+
+// Returns "" when no goals are set
+func (self Model) GoalText() string {
+	return GoalText(self.Goals, self.Markers.WorkChar)
+}
+
+// Returns "" when the current pomodoro wasn't interrupted
+func (self Model) InterruptionText() string {
+	if self.Interruptions.Total() == 0 {
+		return ""
+	}
+	return "Interruptions: " + self.Interruptions.String()
+}
+
+// Returns "" when there are no breaks left
+func (self Model) BreakString() string {
+	remaining := ""
+	for i := 0; i < self.RemainingBreaks; i++ {
+		remaining += self.Markers.BreakChar + " "
+	}
+	if remaining == "" {
+		return ""
+	}
+	return "Breaks Left: " + remaining
+}
+
+func (self Model) TimerText() string {
+	if self.State == timer.DONE {
+		text := "Done! Worked "
+		if self.Aborted {
+			text = "Session ended early. Worked "
+		}
+		text += timer.ShortTimeString(self.TotalWorkTime)
+		text += ", breaks " + timer.ShortTimeString(self.TotalBreakTime)
+		text += ", paused " + timer.ShortTimeString(self.TotalPausedTime)
+		text += ", idle " + timer.ShortTimeString(self.TotalWaitingTime)
+		if self.TotalInterruptions.Total() > 0 {
+			text += ". Interruptions: " + self.TotalInterruptions.String()
+		}
+		return text
+	}
+	if self.State == timer.SCHEDULED {
+		schedule := self.Schedule
+		if schedule == "" {
+			schedule = "Work starts later"
+		}
+		return schedule + " (" + timer.TimeString(self.Remaining) + " left)"
+	}
+	return self.Phase() + ": " + timer.TimeString(self.Remaining)
+}
+
+// One hint per line, e.g. "'p': Pause"
+func (self Model) KeyText() string {
+	lines := make([]string, 0, len(self.Keys))
+	for _, hint := range self.Keys {
+		lines = append(lines, hint.Keys+": "+hint.Label)
+	}
+	return strings.Join(lines, "\n")
+}